@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"mudengine/internal/database"
+)
+
+// serverStats is a snapshot of runtime statistics
+type serverStats struct {
+	Version              string               `json:"version"`
+	UptimeSecs           int64                `json:"uptime_secs"`
+	ConnectedClients     int                  `json:"connected_clients"`
+	AuthenticatedPlayers int                  `json:"authenticated_players"`
+	Goroutines           int                  `json:"goroutines"`
+	Memory               memoryStats          `json:"memory"`
+	DBPool               dbPoolStats          `json:"db_pool"`
+	SendQueues           sendQueueStats       `json:"send_queues"`
+	Autosave             *autosaveStats       `json:"autosave,omitempty"`
+	World                *database.WorldStats `json:"world,omitempty"`
+}
+
+// memoryStats is the subset of runtime.MemStats worth reporting
+type memoryStats struct {
+	AllocBytes     uint64 `json:"alloc_bytes"`
+	HeapInUseBytes uint64 `json:"heap_in_use_bytes"`
+	SysBytes       uint64 `json:"sys_bytes"`
+	NumGC          uint32 `json:"num_gc"`
+}
+
+// dbPoolStats is the subset of sql.DBStats worth reporting
+type dbPoolStats struct {
+	OpenConnections int   `json:"open_connections"`
+	InUse           int   `json:"in_use"`
+	Idle            int   `json:"idle"`
+	WaitCount       int64 `json:"wait_count"`
+}
+
+// sendQueueStats summarises how far behind clients are on their output
+type sendQueueStats struct {
+	MaxDepth        int   `json:"max_depth"`        // Deepest queue of any client
+	TotalDepth      int   `json:"total_depth"`      // Messages queued across all clients
+	Limit           int   `json:"limit"`            // SEND_QUEUE_LIMIT
+	SlowDisconnects int64 `json:"slow_disconnects"` // Clients dropped for exceeding the limit
+}
+
+// collectSendQueueStats measures every client's send queue
+func (s *Server) collectSendQueueStats() sendQueueStats {
+	stats := sendQueueStats{
+		Limit:           s.currentConfig().SendQueueLimit,
+		SlowDisconnects: s.slowDrops.Load(),
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for client := range s.clients {
+		depth := len(client.send)
+		stats.TotalDepth += depth
+		stats.MaxDepth = max(stats.MaxDepth, depth)
+	}
+
+	return stats
+}
+
+// collectStats gathers a snapshot of runtime statistics
+func (s *Server) collectStats() serverStats {
+	connected, authenticated := s.clientCounts()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := serverStats{
+		Version:              s.currentConfig().ServerVersion,
+		UptimeSecs:           int64(time.Since(s.startedAt).Seconds()),
+		ConnectedClients:     connected,
+		AuthenticatedPlayers: authenticated,
+		Goroutines:           runtime.NumGoroutine(),
+		SendQueues:           s.collectSendQueueStats(),
+		Autosave:             s.lastSave.Load(),
+		Memory: memoryStats{
+			AllocBytes:     mem.Alloc,
+			HeapInUseBytes: mem.HeapInuse,
+			SysBytes:       mem.Sys,
+			NumGC:          mem.NumGC,
+		},
+	}
+
+	if database.DB != nil {
+		pool := database.DB.Stats()
+		stats.DBPool = dbPoolStats{
+			OpenConnections: pool.OpenConnections,
+			InUse:           pool.InUse,
+			Idle:            pool.Idle,
+			WaitCount:       pool.WaitCount,
+		}
+	}
+
+	world, err := database.GetWorldStats()
+	if err != nil {
+		log.Printf("Error collecting world stats: %v", err)
+	} else {
+		stats.World = world
+	}
+
+	return stats
+}
+
+// handleAdminStats serves collectStats as JSON to holders of the admin token
+func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.collectStats()); err != nil {
+		log.Printf("Error writing admin stats: %v", err)
+	}
+}
+
+// authorizeAdmin checks the request's bearer token against ADMIN_API_TOKEN
+// and writes an error response when it doesn't match. With no token
+// configured the admin endpoints don't exist.
+func (s *Server) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	token := s.currentConfig().AdminAPIToken
+	if token == "" {
+		http.NotFound(w, r)
+		return false
+	}
+
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		log.Printf("Rejected admin request to %s from %s", r.URL.Path, r.RemoteAddr)
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+
+	return true
+}
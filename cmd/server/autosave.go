@@ -0,0 +1,72 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// autosaveStats describes the most recent autosave pass
+type autosaveStats struct {
+	FinishedAt  time.Time `json:"finished_at"`
+	DurationMs  int64     `json:"duration_ms"`
+	RowsWritten int       `json:"rows_written"`
+}
+
+// autosaveTick is a tick handler that starts an autosave every
+// AUTOSAVE_INTERVAL_SECS. The save runs on its own goroutine so slow
+// writes never hold up other tick handlers, and a pass still running when
+// the next is due is left to finish instead of being doubled up. Shutdown
+// waits on s.autosaves for a pass in flight.
+func (s *Server) autosaveTick(tick uint64) {
+	cfg := s.currentConfig()
+	if cfg.AutosaveIntervalSecs <= 0 {
+		return
+	}
+
+	every := uint64(max(time.Duration(cfg.AutosaveIntervalSecs)*time.Second/s.ticker.Interval(), 1))
+	if tick%every != 0 {
+		return
+	}
+
+	if !s.autosaving.CompareAndSwap(false, true) {
+		log.Println("Skipping autosave, the previous one is still running")
+		return
+	}
+
+	s.autosaves.Add(1)
+	go func() {
+		defer s.autosaves.Done()
+		defer s.autosaving.Store(false)
+		s.autosave()
+	}()
+}
+
+// autosave writes every online player whose state changed since it was
+// last saved. Players are saved one at a time, so a pass uses at most one
+// database connection and locks each player only for their own write.
+//
+// Health is already written as it changes, so location is the only state
+// held in memory.
+func (s *Server) autosave() {
+	start := time.Now()
+	written := 0
+
+	for _, player := range s.presence.snapshot() {
+		s.withClient(player.client, func(c *Client) {
+			if c.saveLocation() {
+				written++
+			}
+		})
+	}
+
+	stats := &autosaveStats{
+		FinishedAt:  time.Now(),
+		DurationMs:  time.Since(start).Milliseconds(),
+		RowsWritten: written,
+	}
+	s.lastSave.Store(stats)
+
+	if written > 0 {
+		log.Printf("Autosaved %d player(s) in %dms", written, stats.DurationMs)
+	}
+}
@@ -2,19 +2,27 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"mudengine/internal/config"
 	"mudengine/internal/database"
+	"mudengine/internal/game"
 
 	"github.com/gorilla/websocket"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // AuthState represents the current authentication state of a connection
@@ -26,49 +34,144 @@ const (
 	StateAwaitingPassword
 	StateAwaitingMFA
 	StateAuthenticated
+	StateRegistering
+	StateAwaitingRulesAccept
 )
 
+// RegistrationStep tracks progress through the new account prompts
+type RegistrationStep int
+
+const (
+	RegStepUsername RegistrationStep = iota
+	RegStepPassword
+	RegStepConfirmPassword
+)
+
+// maxMFARetries is how many consecutive wrong MFA codes are allowed before
+// the login flow restarts from the username prompt
+const maxMFARetries = 2
+
 // Client represents a connected player
 type Client struct {
+	server         *Server
 	conn           *websocket.Conn
 	send           chan []byte
+	commands       chan string // Input waiting for commandPump, in the order it arrived
 	authState      AuthState
 	username       string
 	failedAttempts int
+	mfaFailures    int             // Consecutive MFA failures since the password was accepted
+	mfaSecret      string          // TOTP secret of the player being authenticated, empty if not enrolled
+	playerID       string          // The player's account, set once the password is accepted
+	entityID       string          // The player's entity, set once the password is accepted
+	keys           map[string]bool // Permission keys from the player's roles, loaded at login for the gated commands to come
+	rulesVersion   string          // Rules version the player has accepted, empty if none
+	rulesShown     string          // Rules version awaiting their accept
+	roomID         string          // Room the player is in, persisted to the entity on save
+	savedRoomID    string          // Room last written to the entity; differs from roomID when unsaved
+	darkvision     int             // Copied from the player's entity on login
+	regStep        RegistrationStep
+	regPassword    string        // Held only until registration completes
+	kick           chan string   // Disconnect reason handed to writePump by disconnect or a takeover
+	done           chan struct{} // Closed once the session is saved and out of presence
+	lagging        atomic.Bool   // Set once the client fell too far behind and is being dropped
 	mu             sync.Mutex
 }
 
+// commandQueueSize is how many lines of input a client may have waiting
+// to be processed before it is disconnected for flooding
+const commandQueueSize = 32
+
+// sendBufferSize is how many messages each client's send channel holds,
+// the upper bound for SEND_QUEUE_LIMIT
+const sendBufferSize = 256
+
 // Server manages all connected clients
 type Server struct {
 	clients    map[*Client]bool
 	register   chan *Client
 	unregister chan *Client
 	shutdown   chan struct{}
+	cfg        atomic.Pointer[config.Config] // Swapped on SIGHUP reload
+	limiter    *connRateLimiter
+	presence   presence
+	ticker     *game.Ticker
+	upgrader   websocket.Upgrader
+	draining   atomic.Bool  // Set when shutdown starts; no new connections or logins
+	slowDrops  atomic.Int64 // Clients disconnected for falling behind on output
+	autosaving atomic.Bool  // Set while an autosave pass runs
+	autosaves  sync.WaitGroup
+	lastSave   atomic.Pointer[autosaveStats]
+	startedAt  time.Time
 	mu         sync.RWMutex
 }
 
-// WebSocket upgrader configuration
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// TODO: In production, validate origin properly
-		return true
-	},
-}
-
 // NewServer creates a new server instance
-func NewServer() *Server {
-	return &Server{
+func NewServer(cfg *config.Config) *Server {
+	s := &Server{
 		clients:    make(map[*Client]bool),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		shutdown:   make(chan struct{}),
+		startedAt:  time.Now(),
+		limiter:    newConnRateLimiter(cfg.ConnRateLimit, time.Duration(cfg.ConnRateWindowSecs)*time.Second),
 	}
+
+	s.cfg.Store(cfg)
+
+	// Game tick handlers
+	s.ticker = game.NewTicker(time.Duration(cfg.TickIntervalSecs) * time.Second)
+	s.ticker.Register("room traps", s.applyRoomTraps)
+	s.ticker.Register("autosave", s.autosaveTick)
+
+	// WebSocket upgrader configuration
+	s.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     s.checkOrigin,
+	}
+
+	return s
+}
+
+// currentConfig returns the active configuration, which may be replaced
+// by a reload at any time
+func (s *Server) currentConfig() *config.Config {
+	return s.cfg.Load()
+}
+
+// checkOrigin allows a WebSocket upgrade only from an allowed origin.
+// With no ALLOWED_ORIGINS configured, the origin must match the Host header.
+// Requests without an Origin header come from non-browser clients and
+// can't be used for cross-site hijacking, so they are allowed.
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	allowedOrigins := s.currentConfig().AllowedOrigins
+	if len(allowedOrigins) == 0 {
+		u, err := url.Parse(origin)
+		if err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+	}
+
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+
+	log.Printf("Rejected WebSocket origin %q from %s", origin, r.RemoteAddr)
+	return false
 }
 
 // Run starts the server's main event loop
 func (s *Server) Run() {
+	go s.limiter.pruneLoop(s.shutdown)
+
 	for {
 		select {
 		case client := <-s.register:
@@ -89,10 +192,10 @@ func (s *Server) Run() {
 		case <-s.shutdown:
 			log.Println("Server shutting down, closing all client connections...")
 			s.mu.Lock()
+			// writePump delivers the notice and closes the connection. send
+			// stays open, since commands still running may write to it.
 			for client := range s.clients {
-				client.sendMessage("\r\n\r\nServer is shutting down. Goodbye!\r\n")
-				client.conn.Close()
-				close(client.send)
+				client.disconnect("\r\n\r\nServer is shutting down. Goodbye!\r\n")
 			}
 			s.clients = make(map[*Client]bool)
 			s.mu.Unlock()
@@ -104,29 +207,70 @@ func (s *Server) Run() {
 
 // handleWebSocket handles incoming WebSocket connections
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	if s.draining.Load() {
+		http.Error(w, "Server is shutting down.", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Reject floods before the upgrade allocates a connection and goroutines
+	ip := clientIP(r, s.currentConfig().TrustProxyHeaders)
+	if !s.limiter.allow(ip) {
+		log.Printf("Connection rate limit exceeded for %s", ip)
+		http.Error(w, "Too many connection attempts, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
 		return
 	}
 
 	client := &Client{
+		server:    s,
 		conn:      conn,
-		send:      make(chan []byte, 256),
+		send:      make(chan []byte, sendBufferSize),
+		commands:  make(chan string, commandQueueSize),
+		kick:      make(chan string, 1),
+		done:      make(chan struct{}),
 		authState: StateConnected,
 	}
 
-	s.register <- client
+	select {
+	case s.register <- client:
+	case <-s.shutdown:
+		conn.Close()
+		return
+	}
 
 	// Start goroutines for reading and writing
 	go client.writePump()
 	go client.readPump(s)
 }
 
-// readPump reads messages from the WebSocket connection
+// readPump reads messages from the WebSocket connection and queues them
+// for commandPump
 func (c *Client) readPump(s *Server) {
+	processed := make(chan struct{})
+	go c.commandPump(processed)
+
 	defer func() {
-		s.unregister <- c
+		// Let commands already received finish before the final save
+		close(c.commands)
+		<-processed
+
+		c.mu.Lock()
+		c.saveLocation()
+		c.mu.Unlock()
+
+		s.presence.remove(c)
+		close(c.done)
+
+		// Run no longer receives once it has shut down
+		select {
+		case s.unregister <- c:
+		case <-s.shutdown:
+		}
 		c.conn.Close()
 	}()
 
@@ -149,8 +293,25 @@ func (c *Client) readPump(s *Server) {
 			break
 		}
 
-		// Process the message based on authentication state
-		c.processMessage(string(message))
+		select {
+		case c.commands <- string(message):
+		default:
+			log.Printf("Command queue full for %s (%s), disconnecting", c.username, c.conn.RemoteAddr())
+			c.sendMessage("\r\nToo many commands at once. Disconnecting.\r\n")
+			return
+		}
+	}
+}
+
+// commandPump processes a client's input one line at a time, in the order
+// it arrived, so none of a player's commands ever run concurrently with
+// another of theirs. It returns once readPump closes the queue and every
+// queued command is done, then closes processed.
+func (c *Client) commandPump(processed chan<- struct{}) {
+	defer close(processed)
+
+	for message := range c.commands {
+		c.processMessage(message)
 	}
 }
 
@@ -188,6 +349,17 @@ func (c *Client) writePump() {
 				return
 			}
 
+		case reason := <-c.kick:
+			// Written here so the reason goes out before the close frame,
+			// after anything queued ahead of it
+			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			for n := len(c.send); n > 0; n-- {
+				c.conn.WriteMessage(websocket.TextMessage, <-c.send)
+			}
+			c.conn.WriteMessage(websocket.TextMessage, []byte(reason))
+			c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			return
+
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
@@ -211,6 +383,7 @@ func (c *Client) sendWelcomeBanner() {
 	c.mu.Lock()
 	c.authState = StateAwaitingLogin
 	c.mu.Unlock()
+	c.sendMessage("Type 'register' to create a new account.\r\n\r\n")
 	c.sendMessage("Login: ")
 }
 
@@ -220,6 +393,12 @@ func (c *Client) processMessage(message string) {
 	state := c.authState
 	c.mu.Unlock()
 
+	// Halt logins in progress once shutdown has started
+	if state != StateAuthenticated && c.server.draining.Load() {
+		c.disconnect("\r\nServer is shutting down.\r\n")
+		return
+	}
+
 	switch state {
 	case StateAwaitingLogin:
 		c.handleLogin(message)
@@ -229,6 +408,10 @@ func (c *Client) processMessage(message string) {
 		c.handleMFA(message)
 	case StateAuthenticated:
 		c.handleGameCommand(message)
+	case StateRegistering:
+		c.handleRegistration(message)
+	case StateAwaitingRulesAccept:
+		c.handleRulesAccept(message)
 	default:
 		c.sendMessage("Error: Invalid state\r\n")
 	}
@@ -244,12 +427,112 @@ func (c *Client) handleLogin(username string) {
 		return
 	}
 
+	if strings.EqualFold(username, "register") {
+		c.authState = StateRegistering
+		c.regStep = RegStepUsername
+		c.sendMessage("\r\nCreating a new account.\r\nChoose a username: ")
+		return
+	}
+
 	// TODO: Validate username format
 	c.username = username
 	c.authState = StateAwaitingPassword
 	c.sendMessage("Password: \x1b[8m") // ANSI code to hide input
 }
 
+// handleRegistration walks a new player through the account creation prompts
+func (c *Client) handleRegistration(input string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.regStep {
+	case RegStepUsername:
+		if !validUsername(input) {
+			c.sendMessage("Usernames must be 3 to 16 letters or digits.\r\nChoose a username: ")
+			return
+		}
+		// Catch taken names early; CreatePlayer still enforces uniqueness
+		if _, err := database.GetPlayerByUsername(input); err == nil {
+			c.sendMessage(fmt.Sprintf("The name %s is taken.\r\nChoose a username: ", input))
+			return
+		}
+		c.username = input
+		c.regStep = RegStepPassword
+		c.sendMessage("Choose a password: \x1b[8m")
+
+	case RegStepPassword:
+		c.sendMessage("\x1b[28m")
+		if input == "" {
+			c.sendMessage("Password cannot be empty.\r\nChoose a password: \x1b[8m")
+			return
+		}
+		c.regPassword = input
+		c.regStep = RegStepConfirmPassword
+		c.sendMessage("Confirm password: \x1b[8m")
+
+	case RegStepConfirmPassword:
+		c.sendMessage("\x1b[28m")
+		if input != c.regPassword {
+			c.regPassword = ""
+			c.regStep = RegStepPassword
+			c.sendMessage("Passwords do not match.\r\nChoose a password: \x1b[8m")
+			return
+		}
+		// The account is only created once the rules are accepted
+		if c.promptRules() {
+			return
+		}
+		c.completeRegistration()
+	}
+}
+
+// completeRegistration creates the account once all prompts are answered
+// and logs the new player straight in.
+// Caller must hold c.mu.
+func (c *Client) completeRegistration() {
+	password := c.regPassword
+	c.regPassword = ""
+
+	player, err := database.CreatePlayer(c.username, password)
+	if errors.Is(err, database.ErrUsernameTaken) {
+		c.authState = StateRegistering // May be coming from the rules prompt
+		c.regStep = RegStepUsername
+		c.sendMessage(fmt.Sprintf("\r\nThe name %s is taken.\r\nChoose a username: ", c.username))
+		c.username = ""
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating player %s: %v", c.username, err)
+		c.username = ""
+		c.authState = StateAwaitingLogin
+		c.sendMessage("Account creation failed, please try again later.\r\nLogin: ")
+		return
+	}
+
+	log.Printf("New player registered: %s from %s", c.username, c.conn.RemoteAddr())
+	c.playerID = player.ID
+	c.entityID = player.EntityID
+	if c.rulesVersion != "" {
+		c.recordRulesAccepted()
+	}
+	c.sendMessage("\r\nAccount created.\r\n")
+	c.completeLogin("Welcome")
+}
+
+// validUsername reports whether name is 3 to 16 ASCII letters or digits
+func validUsername(name string) bool {
+	if len(name) < 3 || len(name) > 16 {
+		return false
+	}
+	for _, r := range name {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	// Typing register at the login prompt starts registration instead
+	return !strings.EqualFold(name, "register")
+}
+
 // handlePassword processes the password
 func (c *Client) handlePassword(password string) {
 	c.mu.Lock()
@@ -262,23 +545,26 @@ func (c *Client) handlePassword(password string) {
 		return
 	}
 
-	// TODO: Validate password against database
-	// For now, accept any non-empty password
 	isValid := c.validatePassword(password)
 
 	if !isValid {
-		c.failedAttempts++
-		if c.failedAttempts >= 3 {
-			c.sendMessage("Too many failed attempts. Disconnecting.\r\n")
-			c.conn.Close()
+		remaining, ok := c.recordFailedAttempt()
+		if !ok {
 			return
 		}
-		c.sendMessage(fmt.Sprintf("Invalid credentials. Attempts remaining: %d\r\nLogin: ", 3-c.failedAttempts))
+		c.sendMessage(fmt.Sprintf("Invalid credentials. Attempts remaining: %d\r\nLogin: ", remaining))
 		c.authState = StateAwaitingLogin
 		c.username = ""
 		return
 	}
 
+	// Players without an MFA secret are not enrolled, skip the MFA stage
+	if c.mfaSecret == "" {
+		c.completeLogin("Welcome back")
+		return
+	}
+
+	c.mfaFailures = 0
 	c.authState = StateAwaitingMFA
 	c.sendMessage("MFA Code: ")
 }
@@ -293,68 +579,471 @@ func (c *Client) handleMFA(code string) {
 		return
 	}
 
-	// TODO: Validate TOTP code
-	// For now, accept "123456" as valid
 	isValid := c.validateMFA(code)
 
 	if !isValid {
-		c.failedAttempts++
-		if c.failedAttempts >= 3 {
-			c.sendMessage("Too many failed attempts. Disconnecting.\r\n")
-			c.conn.Close()
+		remaining, ok := c.recordFailedAttempt()
+		if !ok {
+			return
+		}
+
+		// Too many MFA misses force a full re-login so a guessed
+		// password doesn't buy open-ended MFA retries
+		c.mfaFailures++
+		if c.mfaFailures >= maxMFARetries {
+			c.sendMessage(fmt.Sprintf("Invalid MFA code. Please log in again. Attempts remaining: %d\r\nLogin: ", remaining))
+			c.authState = StateAwaitingLogin
+			c.username = ""
+			c.mfaSecret = ""
 			return
 		}
-		c.sendMessage(fmt.Sprintf("Invalid MFA code. Attempts remaining: %d\r\nMFA Code: ", 3-c.failedAttempts))
+
+		c.sendMessage(fmt.Sprintf("Invalid MFA code. Attempts remaining: %d\r\nMFA Code: ", remaining))
 		return
 	}
 
-	c.authState = StateAuthenticated
-	c.sendMessage(fmt.Sprintf("\r\nWelcome back, %s!\r\n\r\n", c.username))
+	c.completeLogin("Welcome back")
+}
 
-	// TODO: Load player's current room from database
-	// For now, show a default room description
-	c.sendInitialLook()
+// completeLogin moves the client into the game once every auth stage passed.
+// Caller must hold c.mu.
+func (c *Client) completeLogin(greeting string) {
+	if c.promptRules() {
+		return
+	}
+
+	if old := c.server.presence.online(c.entityID); old != nil && !c.replaceSession(old) {
+		return
+	}
+
+	room, err := c.loadLocation()
+	if err != nil {
+		log.Printf("Error loading room for %s: %v", c.username, err)
+		c.disconnect("The world fails to take shape around you. Please try again later.\r\n")
+		return
+	}
+
+	// Roles are read afresh each login so promotions apply on next login
+	keys, err := database.GetPlayerKeys(c.playerID)
+	if err != nil {
+		log.Printf("Error loading keys for %s, logging them in without any: %v", c.username, err)
+	}
+	c.keys = keys
+	// Another login for the account may have slipped in since the check
+	if !c.server.presence.add(c, c.username, c.entityID, room.ID) {
+		c.rejectDuplicateLogin()
+		return
+	}
+
+	// Only a full login resets the shared attempt budget
+	c.failedAttempts = 0
+	c.mfaSecret = ""
+	c.authState = StateAuthenticated
+	c.sendMessage(fmt.Sprintf("\r\n%s, %s!\r\n\r\n", greeting, c.username))
+	c.sendLook(room)
 
 	c.sendMessage("> ")
 }
 
-// sendInitialLook sends the room description when player first logs in
-func (c *Client) sendInitialLook() {
-	// TODO: Replace with actual room data from database
-	// This is placeholder content until we implement the room system
-	c.sendMessage("The Town Square\r\n")
-	c.sendMessage("You stand in the bustling town square. A large fountain dominates\r\n")
-	c.sendMessage("the center, with merchants hawking their wares around its edge.\r\n")
-	c.sendMessage("A weathered wooden sign stands near the fountain.\r\n\r\n")
-	c.sendMessage("Obvious exits: north, south, east\r\n")
-	c.sendMessage("You see: a weathered wooden sign\r\n\r\n")
+// sessionTakeoverTimeout is how long a takeover waits for the old session
+// to save and log out
+const sessionTakeoverTimeout = 5 * time.Second
+
+// replaceSession applies DUPLICATE_LOGIN_POLICY to a login for an account
+// that old is already playing. It reports whether this login may go ahead,
+// which under "takeover" means old has been disconnected and has finished
+// saving, so the two sessions never write the player at the same time.
+// Caller must hold c.mu. It is released while waiting for old, since tick
+// handlers lock clients while holding s.mu and would stall the server.
+func (c *Client) replaceSession(old *Client) bool {
+	if c.server.currentConfig().DuplicateLoginPolicy == "reject" {
+		log.Printf("Rejected second login for %s from %s", c.username, c.conn.RemoteAddr())
+		c.rejectDuplicateLogin()
+		return false
+	}
+
+	log.Printf("%s logged in from %s, disconnecting their old session", c.username, c.conn.RemoteAddr())
+	select {
+	case old.kick <- "\r\nYou have been disconnected — logged in elsewhere.\r\n":
+	default:
+		// Already being kicked by another login
+	}
+
+	c.mu.Unlock()
+	loggedOut := false
+	select {
+	case <-old.done:
+		loggedOut = true
+	case <-time.After(sessionTakeoverTimeout):
+	}
+	c.mu.Lock()
+
+	if !loggedOut {
+		log.Printf("Old session for %s did not log out in time", c.username)
+		c.rejectDuplicateLogin()
+		return false
+	}
+
+	return true
+}
+
+// rejectDuplicateLogin turns a login away because the account is already
+// online and sends the client back to the login prompt.
+// Caller must hold c.mu.
+func (c *Client) rejectDuplicateLogin() {
+	c.authState = StateAwaitingLogin
+	c.username = ""
+	c.playerID = ""
+	c.entityID = ""
+	c.keys = nil
+	c.rulesVersion = ""
+	c.mfaSecret = ""
+	c.roomID = ""
+	c.sendMessage("\r\nAlready playing from another connection.\r\nLogin: ")
+}
+
+// recordFailedAttempt counts a failed login attempt and reports how many remain.
+//
+// Attempt rules:
+//   - Every wrong password and every wrong MFA code costs one attempt.
+//   - Both stages draw from one per-connection budget of MAX_LOGIN_ATTEMPTS,
+//     so passing the password stage does not grant fresh MFA tries.
+//   - A wrong password returns to the login prompt. A wrong MFA code
+//     re-prompts for MFA, until maxMFARetries consecutive misses send the
+//     player back to the login prompt as well.
+//   - Empty input is re-prompted and costs nothing.
+//   - The budget resets only when the player is fully authenticated.
+//
+// When the budget is exhausted the client is disconnected and ok is false.
+// Caller must hold c.mu.
+func (c *Client) recordFailedAttempt() (remaining int, ok bool) {
+	maxAttempts := c.server.currentConfig().MaxLoginAttempts
+	c.failedAttempts++
+	log.Printf("Failed login attempt %d/%d for %s from %s", c.failedAttempts, maxAttempts, c.username, c.conn.RemoteAddr())
+
+	if c.failedAttempts >= maxAttempts {
+		// TODO: Feed into persistent account lockout once it exists
+		c.disconnect("Too many failed attempts. Disconnecting.\r\n")
+		return 0, false
+	}
+
+	return maxAttempts - c.failedAttempts, true
+}
+
+// loadLocation loads the room the player's entity was saved in. A room that
+// no longer exists is replaced by the starting room (or Limbo, failing that)
+// and the player's entity is moved there.
+// Caller must hold c.mu.
+func (c *Client) loadLocation() (*database.Room, error) {
+	entity, err := database.GetEntity(c.entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	room, err := database.GetRoom(entity.RoomID)
+	if err != nil {
+		log.Printf("Warning: saved room %s for %s is gone, moving them to the starting room: %v", entity.RoomID, c.username, err)
+
+		room, err = database.GetRoomOrLimbo(database.StartingRoomID)
+		if err != nil {
+			return nil, err
+		}
+		if err := database.MoveEntity(c.entityID, room.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	c.roomID = room.ID
+	c.savedRoomID = room.ID
+	c.darkvision = entity.Darkvision
+	return room, nil
+}
+
+// saveLocation persists the player's current room to their entity, if it
+// changed since the last save, and reports whether it wrote anything.
+// Caller must hold c.mu.
+func (c *Client) saveLocation() bool {
+	if c.authState != StateAuthenticated || c.roomID == "" || c.roomID == c.savedRoomID {
+		return false
+	}
+
+	if err := database.MoveEntity(c.entityID, c.roomID); err != nil {
+		log.Printf("Error saving location for %s: %v", c.username, err)
+		return false
+	}
+
+	c.savedRoomID = c.roomID
+	return true
+}
+
+// sendLook sends the description of a room as the player sees it, on
+// login, on look and after a respawn
+func (c *Client) sendLook(room *database.Room) {
+	sight := c.sight(room)
+	if sight == sightNone {
+		c.sendMessage("It is pitch black. You can't see anything.\r\n\r\n")
+		return
+	}
+
+	c.sendMessage(room.Title + "\r\n")
+	if sight == sightDim {
+		// ANSI faint, for clients that render it
+		c.sendMessage("\x1b[2m" + c.formatRoomDescription(room) + "\x1b[22m\r\n")
+		c.sendMessage("It is dim here, and only the most obvious things stand out.\r\n\r\n")
+	} else {
+		c.sendMessage(c.formatRoomDescription(room) + "\r\n\r\n")
+	}
+
+	names := obviousExits(room)
+	if len(names) == 0 {
+		c.sendMessage("Obvious exits: none\r\n")
+	} else {
+		c.sendMessage(fmt.Sprintf("Obvious exits: %s\r\n", strings.Join(names, ", ")))
+	}
+
+	objects, noticed := visibleObjects(room)
+	if len(objects) > 0 {
+		c.sendMessage(fmt.Sprintf("You see: %s\r\n", strings.Join(objects, ", ")))
+	}
+	if sight == sightClear {
+		noticed = append(noticedExits(room), noticed...)
+		if len(noticed) > 0 {
+			c.sendMessage(fmt.Sprintf("Looking closer, you notice: %s\r\n", strings.Join(noticed, ", ")))
+		}
+	}
+	if players := c.server.presence.playersInRoom(room.ID, c); len(players) > 0 {
+		c.sendMessage(fmt.Sprintf("Players here: %s\r\n", strings.Join(players, ", ")))
+	}
+	if npcs := visibleNPCs(room); len(npcs) > 0 {
+		c.sendMessage(fmt.Sprintf("Also here: %s.\r\n", strings.Join(npcs, ", ")))
+	}
+
+	c.sendMessage("\r\n")
+}
+
+// sightLevel is how well a player can see a room
+type sightLevel int
+
+const (
+	sightClear sightLevel = iota // Everything not hidden can be seen
+	sightDim                     // Only obvious exits and objects can be made out
+	sightNone                    // Pitch black, nothing can be seen
+)
+
+// pitchBlackAbove is the darkness level above which a room can't be seen
+// without a light source or enough darkvision
+const pitchBlackAbove = 7
+
+// sight reports how well the player can see a room. Any darkness dims it
+// and darkness above pitchBlackAbove blacks it out, unless they carry a
+// light or have darkvision at least as strong as the darkness.
+func (c *Client) sight(room *database.Room) sightLevel {
+	if room.Darkness <= 0 || c.darkvision >= room.Darkness {
+		return sightClear
+	}
+
+	lit, err := database.CarriesLight(c.entityID)
+	if err != nil {
+		log.Printf("Error checking light source for %s: %v", c.username, err)
+	}
+	if lit {
+		return sightClear
+	}
+
+	if room.Darkness > pitchBlackAbove {
+		return sightNone
+	}
+	return sightDim
+}
+
+// visibleObjects returns the names of the unhidden objects in a room,
+// alphabetically: the obvious ones, and those only noticed in good light
+func visibleObjects(room *database.Room) (obvious, noticed []string) {
+	objects, err := database.GetObjectsByRoom(room.ID)
+	if err != nil {
+		log.Printf("Error loading objects for room %s: %v", room.ID, err)
+		return nil, nil
+	}
+
+	for _, obj := range objects {
+		switch {
+		case obj.IsHidden:
+			// Found only by searching
+		case obj.IsObvious:
+			obvious = append(obvious, obj.Name)
+		default:
+			noticed = append(noticed, obj.Name)
+		}
+	}
+
+	return obvious, noticed
+}
+
+// visibleNPCs returns the names of the NPCs in a room that aren't hidden.
+// Hidden NPCs need to be searched for.
+func visibleNPCs(room *database.Room) []string {
+	npcs, err := database.GetNPCsByRoom(room.ID)
+	if err != nil {
+		log.Printf("Error loading NPCs for room %s: %v", room.ID, err)
+		return nil
+	}
+
+	var names []string
+	for _, npc := range npcs {
+		if !npc.Entity.IsHidden {
+			names = append(names, npc.Entity.Name)
+		}
+	}
+
+	return names
 }
 
 // handleGameCommand processes authenticated game commands
 func (c *Client) handleGameCommand(command string) {
 	switch command {
 	case "look":
-		c.sendMessage("You are in a dimly lit room. There is a door to the north.\r\n> ")
+		c.mu.Lock()
+		c.look()
+		c.mu.Unlock()
 	case "quit":
-		c.sendMessage("Goodbye!\r\n")
-		c.conn.Close()
+		c.disconnect("Goodbye!\r\n")
 	default:
+		if suggestion := suggestCommand(command, gameCommands); suggestion != "" {
+			c.sendMessage(fmt.Sprintf("Unknown command '%s'. Did you mean '%s'?\r\n> ", command, suggestion))
+			return
+		}
 		c.sendMessage(fmt.Sprintf("Unknown command: %s\r\n> ", command))
 	}
 }
 
-// validatePassword validates the password (placeholder)
+// look shows the player the room they are in. If that room can't be
+// loaded any more, say a builder deleted it, they are moved to Limbo.
+// Caller must hold c.mu.
+func (c *Client) look() {
+	room, err := database.GetRoomOrLimbo(c.roomID)
+	if err != nil {
+		log.Printf("Error loading room %s for %s: %v", c.roomID, c.username, err)
+		c.sendMessage("You can't make out your surroundings.\r\n> ")
+		return
+	}
+
+	if room.ID != c.roomID {
+		c.roomID = room.ID
+		c.server.presence.setRoom(c, room.ID)
+		c.sendMessage("You find yourself in a formless void.\r\n\r\n")
+	}
+
+	c.sendLook(room)
+	c.sendMessage("> ")
+}
+
+// gameCommands lists the commands handleGameCommand understands. Only
+// commands every player may use belong here, since unknown input is
+// matched against them for suggestions.
+var gameCommands = []string{"look", "quit"}
+
+// maxSuggestionDistance is the furthest edit distance a typo may be from
+// a command for it to be suggested
+const maxSuggestionDistance = 2
+
+// suggestCommand returns the command closest to input by edit distance,
+// or "" if none is within maxSuggestionDistance. Ties go to the command
+// listed first.
+func suggestCommand(input string, commands []string) string {
+	input = strings.ToLower(input)
+
+	best, bestDistance := "", maxSuggestionDistance+1
+	for _, command := range commands {
+		if d := levenshtein(input, command); d < bestDistance {
+			best, bestDistance = command, d
+		}
+	}
+
+	return best
+}
+
+// levenshtein returns the number of single-character insertions,
+// deletions and substitutions needed to turn a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// validatePassword checks the password against the player's bcrypt hash
 func (c *Client) validatePassword(password string) bool {
-	// TODO: Implement actual password validation with bcrypt
-	// For now, accept any password for user "admin"
-	return c.username == "admin" && password == "password"
+	player, err := database.GetPlayerByUsername(c.username)
+	if err != nil {
+		if !errors.Is(err, database.ErrPlayerNotFound) {
+			log.Printf("Error looking up player %s: %v", c.username, err)
+		}
+		// Compare anyway so unknown usernames take as long as real ones
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return false
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(player.PasswordHash), []byte(password)) != nil {
+		return false
+	}
+
+	c.username = player.Username // As registered, whatever case they typed
+	c.mfaSecret = player.MFASecret
+	c.rulesVersion = player.RulesVersion
+	c.playerID = player.ID
+	c.entityID = player.EntityID
+	return true
 }
 
-// validateMFA validates the MFA code (placeholder)
+var (
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
+// dummyPasswordHash returns a bcrypt hash used to equalize the timing of
+// failed lookups for usernames that don't exist
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		hash, err := bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+		if err != nil {
+			log.Printf("Error generating dummy password hash: %v", err)
+		}
+		dummyHash = hash
+	})
+	return dummyHash
+}
+
+// validateMFA validates a TOTP code against the player's MFA secret,
+// allowing MFA_SKEW_STEPS time steps of clock drift either way
 func (c *Client) validateMFA(code string) bool {
-	// TODO: Implement actual TOTP validation
-	// For now, accept "123456"
-	return code == "123456"
+	valid, err := totp.ValidateCustom(code, c.mfaSecret, time.Now().UTC(), totp.ValidateOpts{
+		Period:    30,
+		Skew:      uint(c.server.currentConfig().MFASkewSteps),
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	if err != nil && !errors.Is(err, otp.ErrValidateInputInvalidLength) {
+		// A wrong-length code is just a bad guess; anything else means a bad secret
+		log.Printf("Error validating MFA code for %s: %v", c.username, err)
+	}
+
+	return valid
 }
 
 // Shutdown initiates graceful shutdown
@@ -362,22 +1051,70 @@ func (s *Server) Shutdown() {
 	close(s.shutdown)
 }
 
-// sendMessage sends a message to the client
+// sendMessage queues a message for the client. It never blocks: a client
+// with SEND_QUEUE_LIMIT messages still waiting to be written is
+// disconnected instead, so a slow connection can't hold up whoever is
+// sending to it.
 func (c *Client) sendMessage(message string) {
+	if c.lagging.Load() {
+		return
+	}
+
+	if len(c.send) < c.server.currentConfig().SendQueueLimit {
+		select {
+		case c.send <- []byte(message):
+			return
+		default:
+		}
+	}
+
+	c.dropSlowClient()
+}
+
+// disconnect sends reason and closes the connection. The reason goes
+// through writePump, so unlike sendMessage followed by closing the
+// connection it is written before the close frame.
+func (c *Client) disconnect(reason string) {
 	select {
-	case c.send <- []byte(message):
+	case c.kick <- reason:
 	default:
-		// Channel full, client too slow
-		log.Printf("Client send buffer full for %s", c.username)
+		// Already being disconnected
+		c.conn.Close()
 	}
 }
 
+// dropSlowClient disconnects a client that has stopped keeping up with its
+// output. Closing the connection unblocks writePump and ends readPump,
+// which saves and unregisters the client as usual.
+func (c *Client) dropSlowClient() {
+	if !c.lagging.CompareAndSwap(false, true) {
+		return
+	}
+
+	c.server.slowDrops.Add(1)
+	log.Printf("Disconnecting %s (%s): %d messages queued, too far behind", c.username, c.conn.RemoteAddr(), len(c.send))
+	c.conn.Close()
+}
+
 const (
 	ServerVersion = "0.1.0"
 	ServerName    = "MUD Engine"
 )
 
 func main() {
+	// SIGINT (Ctrl+C) and SIGTERM shut down gracefully, SIGHUP reloads the
+	// config and SIGUSR1 dumps runtime stats to the log. Installed first so
+	// a Ctrl+C during startup is caught too.
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR1)
+
+	startup, abortStartup := context.WithCancel(context.Background())
+	started, watcherDone := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(watcherDone)
+		watchStartupSignals(sigChan, abortStartup, started)
+	}()
+
 	// Load configuration from .env file
 	// Use -env flag to specify custom file: go run main.go -env custom.env
 	cfg, err := config.LoadConfig()
@@ -391,16 +1128,31 @@ func main() {
 	log.Printf("%s v%s starting up...", cfg.ServerName, cfg.ServerVersion)
 
 	// Initialize database
-	if err := database.Initialize(cfg); err != nil {
+	if err := database.Initialize(startup, cfg); err != nil {
+		if startup.Err() != nil {
+			log.Println("Startup interrupted, database closed. Exiting.")
+			return
+		}
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer database.Close()
 
-	server := NewServer()
+	// Hand sigChan over to the main loop, then take a last chance to abort
+	// before players can connect
+	close(started)
+	<-watcherDone
+	if startup.Err() != nil {
+		log.Println("Startup interrupted. Exiting.")
+		return
+	}
+
+	server := NewServer(cfg)
 	go server.Run()
+	server.ticker.Start()
 
 	// HTTP handlers
 	http.HandleFunc("/ws", server.handleWebSocket)
+	http.HandleFunc("/admin/stats", server.handleAdminStats)
 
 	// Serve static files for web client
 	// This serves all files from web/static directory
@@ -416,10 +1168,6 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	// Set up graceful shutdown on SIGINT (Ctrl+C) or SIGTERM
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
 	// Start HTTP server in a goroutine
 	go func() {
 		log.Printf("%s v%s ready", cfg.ServerName, cfg.ServerVersion)
@@ -432,10 +1180,41 @@ func main() {
 		}
 	}()
 
-	// Wait for shutdown signal
-	sig := <-sigChan
-	log.Printf("\nReceived signal: %v", sig)
-	performGracefulShutdown(server, httpServer, cfg)
+	// Handle operational signals until one asks for shutdown
+	for sig := range sigChan {
+		switch sig {
+		case syscall.SIGHUP:
+			log.Println("Received SIGHUP, reloading configuration...")
+			server.reloadConfig()
+		case syscall.SIGUSR1:
+			server.logStats()
+		default:
+			log.Printf("\nReceived signal: %v", sig)
+			performGracefulShutdown(server, httpServer, server.currentConfig())
+			return
+		}
+	}
+}
+
+// watchStartupSignals handles signals until the server has started,
+// cancelling the startup context on SIGINT or SIGTERM. Once started is
+// closed it returns and main's signal loop takes over sigChan.
+func watchStartupSignals(sigChan <-chan os.Signal, abort context.CancelFunc, started <-chan struct{}) {
+	for {
+		select {
+		case <-started:
+			return
+		case sig := <-sigChan:
+			switch sig {
+			case syscall.SIGHUP, syscall.SIGUSR1:
+				log.Printf("Received %v during startup, ignoring", sig)
+			default:
+				log.Printf("\nReceived signal: %v, aborting startup...", sig)
+				abort()
+				return
+			}
+		}
+	}
 }
 
 // performGracefulShutdown handles the shutdown sequence
@@ -444,18 +1223,19 @@ func performGracefulShutdown(server *Server, httpServer *http.Server, cfg *confi
 
 	// Step 1: Stop accepting new connections
 	log.Println("[1/5] Stopping new connections...")
+	server.draining.Store(true)
+	server.ticker.Stop() // No tick may touch players once saving starts
+	server.autosaves.Wait()
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSecs)*time.Second)
 	defer cancel()
 
-	// Step 2: Notify all connected players
-	log.Println("[2/5] Notifying connected players...")
-	server.Shutdown() // This sends messages to clients and closes connections
-
-	// Step 3: Save all player data
-	log.Println("[3/5] Saving player data...")
-	// TODO: Save all authenticated players' locations and status to database
+	// Step 2: Save all player data while the clients are still registered
+	log.Println("[2/5] Saving player data...")
 	saveAllPlayerData(server)
-	time.Sleep(500 * time.Millisecond) // Simulate database writes
+
+	// Step 3: Notify all connected players
+	log.Println("[3/5] Notifying connected players...")
+	server.Shutdown() // This sends messages to clients and closes connections
 
 	// Step 4: Flush pending database writes
 	log.Println("[4/5] Flushing database writes...")
@@ -473,24 +1253,24 @@ func performGracefulShutdown(server *Server, httpServer *http.Server, cfg *confi
 
 // saveAllPlayerData saves all connected players' current state
 func saveAllPlayerData(server *Server) {
-	// TODO: Implement when we have database layer
 	server.mu.RLock()
 	defer server.mu.RUnlock()
 
 	playerCount := 0
 	for client := range server.clients {
-		if client.authState == StateAuthenticated {
-			// TODO: Save player location, health, inventory, etc.
-			// For now, just log
-			log.Printf("  - Saving player: %s", client.username)
+		client.mu.Lock()
+		// TODO: Save health, inventory, etc. once they are tracked in memory
+		if client.saveLocation() {
+			log.Printf("  - Saved player: %s", client.username)
 			playerCount++
 		}
+		client.mu.Unlock()
 	}
 
 	if playerCount > 0 {
 		log.Printf("  Saved %d player(s)", playerCount)
 	} else {
-		log.Println("  No authenticated players to save")
+		log.Println("  No unsaved player state")
 	}
 }
 
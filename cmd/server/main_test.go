@@ -0,0 +1,538 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mudengine/internal/config"
+	"mudengine/internal/database"
+
+	"github.com/gorilla/websocket"
+	"github.com/pquerna/otp/totp"
+)
+
+// testMFASecret is the TOTP secret given to test players enrolled in MFA
+const testMFASecret = "JBSWY3DPEHPK3PXP"
+
+// newTestServer starts a server on a fresh SQLite database and returns it
+// with its WebSocket URL. Everything is shut down when the test ends.
+func newTestServer(t *testing.T, configure func(cfg *config.Config)) (*Server, string) {
+	t.Helper()
+
+	cfg := &config.Config{
+		DBType:               "sqlite",
+		DBName:               filepath.Join(t.TempDir(), "test.db"),
+		DBMaxConnections:     4,
+		DBMaxIdleConns:       4,
+		SendQueueLimit:       sendBufferSize,
+		TickIntervalSecs:     1,
+		MaxLoginAttempts:     3,
+		MFASkewSteps:         1,
+		DuplicateLoginPolicy: "takeover",
+		ConnRateWindowSecs:   60,
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+
+	if err := database.Initialize(context.Background(), cfg); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	s := NewServer(cfg)
+	go s.Run()
+	httpServer := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+
+	t.Cleanup(func() {
+		httpServer.Close()
+		s.Shutdown()
+		database.Close()
+	})
+
+	return s, "ws" + strings.TrimPrefix(httpServer.URL, "http")
+}
+
+// createTestPlayer registers an account, enrolled in MFA if mfa is set
+func createTestPlayer(t *testing.T, username, password string, mfa bool) *database.Player {
+	t.Helper()
+
+	player, err := database.CreatePlayer(username, password)
+	if err != nil {
+		t.Fatalf("CreatePlayer(%q): %v", username, err)
+	}
+
+	if mfa {
+		if _, err := database.DB.Exec("UPDATE players SET mfa_secret = ? WHERE id = ?", testMFASecret, player.ID); err != nil {
+			t.Fatalf("enrolling %s in MFA: %v", username, err)
+		}
+	}
+
+	return player
+}
+
+// testConn is a WebSocket client that reads the server's output as one stream
+type testConn struct {
+	t    *testing.T
+	conn *websocket.Conn
+	buf  string
+}
+
+// dial connects to the server and waits for the login prompt
+func dial(t *testing.T, url string) *testConn {
+	t.Helper()
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", url, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	tc := &testConn{t: t, conn: conn}
+	tc.expect("Login: ")
+	return tc
+}
+
+// send writes one line of input
+func (tc *testConn) send(line string) {
+	tc.t.Helper()
+
+	if err := tc.conn.WriteMessage(websocket.TextMessage, []byte(line)); err != nil {
+		tc.t.Fatalf("send %q: %v", line, err)
+	}
+}
+
+// expect reads output until want appears, failing the test if it doesn't
+// within a few seconds. Output up to and including want is consumed and
+// returned.
+func (tc *testConn) expect(want string) string {
+	tc.t.Helper()
+
+	tc.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if i := strings.Index(tc.buf, want); i >= 0 {
+			out := tc.buf[:i+len(want)]
+			tc.buf = tc.buf[i+len(want):]
+			return out
+		}
+
+		_, message, err := tc.conn.ReadMessage()
+		if err != nil {
+			tc.t.Fatalf("waiting for %q: %v\nreceived: %q", want, err, tc.buf)
+		}
+		tc.buf += string(message)
+	}
+}
+
+// expectClosed reads until the server closes the connection
+func (tc *testConn) expectClosed() {
+	tc.t.Helper()
+
+	tc.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := tc.conn.ReadMessage(); err != nil {
+			if ne, ok := err.(interface{ Timeout() bool }); ok && ne.Timeout() {
+				tc.t.Fatal("connection still open")
+			}
+			return
+		}
+	}
+}
+
+// onlyClient returns the server's one connected client
+func onlyClient(t *testing.T, s *Server) *Client {
+	t.Helper()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.clients) != 1 {
+		t.Fatalf("server has %d clients, want 1", len(s.clients))
+	}
+	for client := range s.clients {
+		return client
+	}
+	return nil
+}
+
+// authState returns a client's state, waiting for its current command to finish
+func authState(c *Client) AuthState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.authState
+}
+
+// mfaCode returns the current TOTP code for testMFASecret
+func mfaCode(t *testing.T) string {
+	t.Helper()
+
+	code, err := totp.GenerateCode(testMFASecret, time.Now())
+	if err != nil {
+		t.Fatalf("GenerateCode: %v", err)
+	}
+	return code
+}
+
+// wrongMFACode returns a six digit code that isn't currently valid
+func wrongMFACode(t *testing.T) string {
+	t.Helper()
+
+	if mfaCode(t) == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func TestPasswordAndMFAShareAttemptBudget(t *testing.T) {
+	s, url := newTestServer(t, nil)
+	createTestPlayer(t, "alice", "correct horse", true)
+
+	tc := dial(t, url)
+	client := onlyClient(t, s)
+
+	tc.send("alice")
+	tc.expect("Password: ")
+	tc.send("wrong")
+	tc.expect("Attempts remaining: 2")
+	tc.expect("Login: ")
+
+	// Passing the password stage moves on to MFA without a fresh budget
+	tc.send("alice")
+	tc.expect("Password: ")
+	tc.send("correct horse")
+	tc.expect("MFA Code: ")
+	if state := authState(client); state != StateAwaitingMFA {
+		t.Fatalf("state after the password = %v, want StateAwaitingMFA", state)
+	}
+
+	tc.send(wrongMFACode(t))
+	tc.expect("Invalid MFA code. Attempts remaining: 1")
+	if state := authState(client); state != StateAwaitingMFA {
+		t.Errorf("state after one bad code = %v, want StateAwaitingMFA", state)
+	}
+
+	tc.send(wrongMFACode(t))
+	tc.expect("Too many failed attempts. Disconnecting.")
+	tc.expectClosed()
+}
+
+func TestFullLoginResetsAttemptBudget(t *testing.T) {
+	s, url := newTestServer(t, nil)
+	createTestPlayer(t, "alice", "correct horse", true)
+
+	tc := dial(t, url)
+	client := onlyClient(t, s)
+
+	tc.send("alice")
+	tc.expect("Password: ")
+	tc.send("wrong")
+	tc.expect("Login: ")
+	tc.send("alice")
+	tc.expect("Password: ")
+	tc.send("correct horse")
+	tc.expect("MFA Code: ")
+	tc.send(mfaCode(t))
+	tc.expect("Welcome back, alice!")
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.authState != StateAuthenticated || client.failedAttempts != 0 {
+		t.Errorf("state, failed attempts = %v, %d; want StateAuthenticated, 0", client.authState, client.failedAttempts)
+	}
+}
+
+func TestBadMFAResetsToLogin(t *testing.T) {
+	s, url := newTestServer(t, func(cfg *config.Config) { cfg.MaxLoginAttempts = 5 })
+	createTestPlayer(t, "alice", "correct horse", true)
+
+	tc := dial(t, url)
+	client := onlyClient(t, s)
+
+	tc.send("alice")
+	tc.expect("Password: ")
+	tc.send("correct horse")
+	tc.expect("MFA Code: ")
+
+	for i := 1; i < maxMFARetries; i++ {
+		tc.send(wrongMFACode(t))
+		tc.expect("MFA Code: ")
+	}
+	tc.send(wrongMFACode(t))
+	tc.expect("Please log in again.")
+	tc.expect("Login: ")
+
+	client.mu.Lock()
+	state, username, secret := client.authState, client.username, client.mfaSecret
+	client.mu.Unlock()
+	if state != StateAwaitingLogin {
+		t.Errorf("state = %v, want StateAwaitingLogin", state)
+	}
+	if username != "" || secret != "" {
+		t.Errorf("username, MFA secret = %q, %q; want both cleared", username, secret)
+	}
+
+	// A correct code is no use without logging in again
+	tc.send(mfaCode(t))
+	tc.expect("Password: ")
+	if state := authState(client); state != StateAwaitingPassword {
+		t.Errorf("state after sending a code at the login prompt = %v, want StateAwaitingPassword", state)
+	}
+}
+
+// regState returns a client's state and registration step
+func regState(c *Client) (AuthState, RegistrationStep) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.authState, c.regStep
+}
+
+func TestRegistrationSteps(t *testing.T) {
+	s, url := newTestServer(t, nil)
+	createTestPlayer(t, "alice", "correct horse", false)
+
+	tc := dial(t, url)
+	client := onlyClient(t, s)
+
+	steps := []struct {
+		input  string
+		expect string
+		state  AuthState
+		step   RegistrationStep
+	}{
+		{"register", "Choose a username: ", StateRegistering, RegStepUsername},
+		{"ab", "3 to 16 letters or digits", StateRegistering, RegStepUsername},
+		{"alice", "The name alice is taken.", StateRegistering, RegStepUsername},
+		{"bob", "Choose a password: ", StateRegistering, RegStepPassword},
+		{"", "Password cannot be empty.", StateRegistering, RegStepPassword},
+		{"hunter2", "Confirm password: ", StateRegistering, RegStepConfirmPassword},
+		{"hunter3", "Passwords do not match.", StateRegistering, RegStepPassword},
+		{"hunter2", "Confirm password: ", StateRegistering, RegStepConfirmPassword},
+		{"hunter2", "Welcome, bob!", StateAuthenticated, RegStepConfirmPassword},
+	}
+
+	for _, step := range steps {
+		tc.send(step.input)
+		tc.expect(step.expect)
+
+		state, regStep := regState(client)
+		if state != step.state || (state == StateRegistering && regStep != step.step) {
+			t.Fatalf("after %q: state, step = %v, %v; want %v, %v", step.input, state, regStep, step.state, step.step)
+		}
+	}
+
+	if _, err := database.GetPlayerByUsername("bob"); err != nil {
+		t.Errorf("registered account not found: %v", err)
+	}
+}
+
+func TestDrainingHaltsLogins(t *testing.T) {
+	s, url := newTestServer(t, nil)
+
+	tc := dial(t, url)
+	s.draining.Store(true)
+
+	tc.send("alice")
+	tc.expect("Server is shutting down.")
+	tc.expectClosed()
+}
+
+// login logs a player without MFA in and waits for the first prompt
+func login(t *testing.T, url, username, password string) *testConn {
+	t.Helper()
+
+	tc := dial(t, url)
+	tc.send(username)
+	tc.expect("Password: ")
+	tc.send(password)
+	tc.expect("Welcome back, " + username + "!")
+	tc.expect("> ")
+	return tc
+}
+
+func TestLookShowsCurrentRoom(t *testing.T) {
+	_, url := newTestServer(t, nil)
+	createTestPlayer(t, "alice", "correct horse", false)
+	createTestPlayer(t, "bob", "battery staple", false)
+
+	login(t, url, "alice", "correct horse")
+	bob := login(t, url, "bob", "battery staple")
+
+	bob.send("look")
+	bob.expect("The Town Square")
+	bob.expect("Obvious exits: none")
+	bob.expect("Players here: alice")
+	bob.expect("> ")
+}
+
+func TestLookFromMissingRoomGoesToLimbo(t *testing.T) {
+	s, url := newTestServer(t, nil)
+	createTestPlayer(t, "alice", "correct horse", false)
+
+	tc := login(t, url, "alice", "correct horse")
+
+	// Rooms with entities in them can't be deleted, so lose it in memory
+	client := onlyClient(t, s)
+	client.mu.Lock()
+	client.roomID = "no-such-room"
+	client.mu.Unlock()
+
+	tc.send("look")
+	tc.expect("You find yourself in a formless void.")
+	tc.expect("Limbo")
+	tc.expect("Obvious exits: out")
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.roomID != database.LimboRoomID {
+		t.Errorf("player is in %s, want Limbo", client.roomID)
+	}
+}
+
+func TestLookRespectsDarkness(t *testing.T) {
+	s, url := newTestServer(t, nil)
+	createTestPlayer(t, "alice", "correct horse", false)
+	tc := login(t, url, "alice", "correct horse")
+	client := onlyClient(t, s)
+
+	newRoom := func(darkness int) *database.Room {
+		t.Helper()
+
+		room := &database.Room{
+			ZoneID:      "10000000-0000-0000-0000-000000000001",
+			Title:       fmt.Sprintf("Cave at darkness %d", darkness),
+			Description: "Damp rock walls.",
+			Darkness:    darkness,
+		}
+		if err := database.CreateRoom(room); err != nil {
+			t.Fatalf("CreateRoom: %v", err)
+		}
+
+		exits := []*database.Exit{
+			{ToRoomID: database.StartingRoomID, Keywords: []string{"north"}, IsObvious: true, IsOpen: true},
+			{ToRoomID: database.StartingRoomID, Keywords: []string{"crawlspace"}, IsOpen: true},
+			{ToRoomID: database.StartingRoomID, Keywords: []string{"tunnel"}, IsHidden: true, IsOpen: true},
+		}
+		for _, exit := range exits {
+			exit.FromRoomID = room.ID
+			if err := database.CreateExit(exit); err != nil {
+				t.Fatalf("CreateExit: %v", err)
+			}
+		}
+
+		objects := []*database.GameObject{
+			{Name: "a lantern post", IsObvious: true},
+			{Name: "a loose pebble"},
+			{Name: "a buried coin", IsHidden: true},
+		}
+		for _, obj := range objects {
+			obj.Description = "Part of the cave."
+			obj.ObjectType = "misc"
+			obj.ContainerID = room.ID
+			obj.ContainerType = database.ContainerTypeRoom
+			if err := database.CreateObject(obj); err != nil {
+				t.Fatalf("CreateObject: %v", err)
+			}
+		}
+
+		return room
+	}
+
+	// look returns everything look sends in room
+	look := func(room *database.Room) string {
+		t.Helper()
+
+		client.mu.Lock()
+		client.roomID = room.ID
+		client.mu.Unlock()
+
+		tc.send("look")
+		return tc.expect("> ")
+	}
+
+	tests := []struct {
+		darkness int
+		want     []string
+		dontWant []string
+	}{
+		{0, []string{"Damp rock walls.", "Obvious exits: north", "You see: a lantern post", "you notice: crawlspace, a loose pebble"},
+			[]string{"dim", "tunnel", "coin"}},
+		{5, []string{"Damp rock walls.", "It is dim here", "Obvious exits: north", "You see: a lantern post"},
+			[]string{"crawlspace", "pebble", "tunnel", "coin"}},
+		{9, []string{"It is pitch black."},
+			[]string{"Damp rock walls.", "north", "lantern"}},
+	}
+
+	for _, tt := range tests {
+		out := look(newRoom(tt.darkness))
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("darkness %d: look output lacks %q:\n%s", tt.darkness, want, out)
+			}
+		}
+		for _, dontWant := range tt.dontWant {
+			if strings.Contains(out, dontWant) {
+				t.Errorf("darkness %d: look output shows %q:\n%s", tt.darkness, dontWant, out)
+			}
+		}
+	}
+}
+
+func TestSecondLoginTakesOverSession(t *testing.T) {
+	_, url := newTestServer(t, nil)
+	createTestPlayer(t, "alice", "correct horse", false)
+
+	first := login(t, url, "alice", "correct horse")
+	login(t, url, "alice", "correct horse")
+
+	first.expect("logged in elsewhere")
+	first.expectClosed()
+}
+
+func TestSecondLoginRejected(t *testing.T) {
+	_, url := newTestServer(t, func(cfg *config.Config) { cfg.DuplicateLoginPolicy = "reject" })
+	createTestPlayer(t, "alice", "correct horse", false)
+
+	first := login(t, url, "alice", "correct horse")
+
+	second := dial(t, url)
+	second.send("alice")
+	second.expect("Password: ")
+	second.send("correct horse")
+	second.expect("Already playing from another connection.")
+	second.expect("Login: ")
+
+	first.send("look")
+	first.expect("The Town Square")
+}
+
+func TestCommandsRunInOrder(t *testing.T) {
+	_, url := newTestServer(t, nil)
+	createTestPlayer(t, "alice", "correct horse", false)
+
+	tc := login(t, url, "alice", "correct horse")
+
+	const n = 20
+	for i := range n {
+		tc.send(fmt.Sprintf("zz%02d", i))
+	}
+	out := tc.expect(fmt.Sprintf("Unknown command: zz%02d", n-1))
+
+	last := -1
+	for i := range n {
+		at := strings.Index(out, fmt.Sprintf("Unknown command: zz%02d", i))
+		if at < 0 {
+			t.Fatalf("no reply to command %d in %q", i, out)
+		}
+		if at < last {
+			t.Errorf("reply to command %d came before the one to command %d", i, i-1)
+		}
+		last = at
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import "sync"
+
+// onlinePlayer is an authenticated player's entry in the presence list
+type onlinePlayer struct {
+	client   *Client
+	username string
+	entityID string
+	roomID   string
+}
+
+// presence tracks which players are logged in and where, in login order.
+// It has its own lock so one client can see others without taking their
+// Client.mu, which would risk lock-order deadlocks.
+type presence struct {
+	players []*onlinePlayer
+	mu      sync.RWMutex
+}
+
+// add records a player as online in roomID. It reports false, adding
+// nothing, if another client is already online as the same entity.
+func (p *presence) add(client *Client, username, entityID, roomID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, player := range p.players {
+		if player.entityID == entityID {
+			return false
+		}
+	}
+
+	p.players = append(p.players, &onlinePlayer{
+		client:   client,
+		username: username,
+		entityID: entityID,
+		roomID:   roomID,
+	})
+	return true
+}
+
+// online returns the client playing as entityID, or nil if none is
+func (p *presence) online(entityID string) *Client {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, player := range p.players {
+		if player.entityID == entityID {
+			return player.client
+		}
+	}
+
+	return nil
+}
+
+// remove drops a client from the presence list, if it is online
+func (p *presence) remove(client *Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, player := range p.players {
+		if player.client == client {
+			p.players = append(p.players[:i], p.players[i+1:]...)
+			return
+		}
+	}
+}
+
+// setRoom updates the room an online client is in
+func (p *presence) setRoom(client *Client, roomID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, player := range p.players {
+		if player.client == client {
+			player.roomID = roomID
+			return
+		}
+	}
+}
+
+// playersInRoom returns the usernames of players in roomID in login order,
+// leaving out exclude
+func (p *presence) playersInRoom(roomID string, exclude *Client) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var names []string
+	for _, player := range p.players {
+		if player.roomID == roomID && player.client != exclude {
+			names = append(names, player.username)
+		}
+	}
+
+	return names
+}
+
+// snapshot returns a copy of the presence list, for callers that need to
+// act on players without holding the lock
+func (p *presence) snapshot() []onlinePlayer {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	players := make([]onlinePlayer, len(p.players))
+	for i, player := range p.players {
+		players[i] = *player
+	}
+
+	return players
+}
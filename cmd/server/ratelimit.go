@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// connRateLimiter tracks WebSocket handshake attempts per IP in a sliding window
+type connRateLimiter struct {
+	limit    int
+	window   time.Duration
+	attempts map[string][]time.Time
+	mu       sync.Mutex
+}
+
+// newConnRateLimiter creates a limiter allowing limit attempts per window.
+// A limit of 0 disables limiting.
+func newConnRateLimiter(limit int, window time.Duration) *connRateLimiter {
+	return &connRateLimiter{
+		limit:    limit,
+		window:   window,
+		attempts: make(map[string][]time.Time),
+	}
+}
+
+// setLimits changes the limit and window, used when the config is reloaded.
+// The prune interval keeps the window the limiter was created with.
+func (l *connRateLimiter) setLimits(limit int, window time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = limit
+	l.window = window
+}
+
+// allow records an attempt from ip and reports whether it is within the limit
+func (l *connRateLimiter) allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit == 0 {
+		return true
+	}
+
+	now := time.Now()
+	recent := l.recentAttempts(ip, now)
+	if len(recent) >= l.limit {
+		l.attempts[ip] = recent
+		return false
+	}
+
+	l.attempts[ip] = append(recent, now)
+	return true
+}
+
+// recentAttempts returns ip's attempts still inside the window.
+// Caller must hold l.mu.
+func (l *connRateLimiter) recentAttempts(ip string, now time.Time) []time.Time {
+	cutoff := now.Add(-l.window)
+	times := l.attempts[ip]
+
+	// Attempts are appended in order, so drop from the front
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+// prune removes IPs with no attempts left inside the window
+func (l *connRateLimiter) prune() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for ip := range l.attempts {
+		if recent := l.recentAttempts(ip, now); len(recent) == 0 {
+			delete(l.attempts, ip)
+		} else {
+			l.attempts[ip] = recent
+		}
+	}
+}
+
+// pruneLoop prunes stale entries once per window until stop is closed
+func (l *connRateLimiter) pruneLoop(stop <-chan struct{}) {
+	l.mu.Lock()
+	interval := l.window
+	l.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.prune()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// clientIP returns the remote IP of a request. With trustProxy set, the
+// last X-Forwarded-For entry is used, since that is the address our own
+// proxy saw; earlier entries can be forged by the client.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			parts := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
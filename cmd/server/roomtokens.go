@@ -0,0 +1,68 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mudengine/internal/database"
+)
+
+// roomTokenPattern matches a {token} in a room description
+var roomTokenPattern = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// formatRoomDescription expands the tokens builders can put in a room
+// description, against the room as the player sees it right now:
+//
+//	{players}  number of other players in the room
+//	{exits}    the obvious exits, comma-separated, or "none"
+//
+// Any other {token} is left as typed, or removed if STRIP_UNKNOWN_TOKENS
+// is set. Only builder-written descriptions go through here; player text
+// such as say and emote must never be expanded.
+func (c *Client) formatRoomDescription(room *database.Room) string {
+	strip := c.server.currentConfig().StripUnknownTokens
+
+	return roomTokenPattern.ReplaceAllStringFunc(room.Description, func(token string) string {
+		switch roomTokenPattern.FindStringSubmatch(token)[1] {
+		case "players":
+			return strconv.Itoa(len(c.server.presence.playersInRoom(room.ID, c)))
+		case "exits":
+			if exits := obviousExits(room); len(exits) > 0 {
+				return strings.Join(exits, ", ")
+			}
+			return "none"
+		}
+
+		if strip {
+			return ""
+		}
+		return token
+	})
+}
+
+// obviousExits returns the names of the exits a player can see leaving a
+// room, by their first keyword
+func obviousExits(room *database.Room) []string {
+	var names []string
+	for _, exit := range room.Exits {
+		if exit.IsObvious && !exit.IsHidden && len(exit.Keywords) > 0 {
+			names = append(names, exit.Keywords[0])
+		}
+	}
+
+	return names
+}
+
+// noticedExits returns the names of the unhidden exits leaving a room that
+// aren't obvious, which players only notice in good light
+func noticedExits(room *database.Room) []string {
+	var names []string
+	for _, exit := range room.Exits {
+		if !exit.IsObvious && !exit.IsHidden && len(exit.Keywords) > 0 {
+			names = append(names, exit.Keywords[0])
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"mudengine/internal/database"
+)
+
+// promptRules shows the server rules and waits for the player to accept
+// them, if RULES_FILE is set and the player hasn't accepted its current
+// RULES_VERSION. It reports whether the rules were shown, in which case
+// the caller must stop until handleRulesAccept picks the login back up.
+//
+// A rules file that can't be read is logged and doesn't block logins.
+// Caller must hold c.mu.
+func (c *Client) promptRules() bool {
+	cfg := c.server.currentConfig()
+	if cfg.RulesFile == "" || c.rulesVersion == cfg.RulesVersion {
+		return false
+	}
+
+	text, err := os.ReadFile(cfg.RulesFile)
+	if err != nil {
+		log.Printf("Error reading rules file %s, not asking %s to accept: %v", cfg.RulesFile, c.username, err)
+		return false
+	}
+
+	c.rulesShown = cfg.RulesVersion
+	c.authState = StateAwaitingRulesAccept
+
+	rules := strings.ReplaceAll(strings.TrimRight(string(text), "\r\n"), "\n", "\r\n")
+	c.sendMessage("\r\n" + rules + "\r\n\r\n")
+	c.sendMessage("Type 'accept' to agree to these rules, or 'decline' to leave: ")
+	return true
+}
+
+// handleRulesAccept processes the answer to the rules prompt. Accepting
+// carries on with the registration or login that showed them. Declining
+// disconnects; a new player's account was never created, so nothing is
+// left behind.
+func (c *Client) handleRulesAccept(input string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "accept":
+		c.rulesVersion = c.rulesShown
+		c.rulesShown = ""
+
+		// Registration stopped just short of creating the account
+		if c.playerID == "" {
+			c.completeRegistration()
+			return
+		}
+
+		c.recordRulesAccepted()
+		c.completeLogin("Welcome back")
+
+	case "decline":
+		log.Printf("%s declined the rules from %s", c.username, c.conn.RemoteAddr())
+		c.regPassword = ""
+		c.disconnect("\r\nYou must accept the rules to play. Goodbye.\r\n")
+
+	default:
+		c.sendMessage("Type 'accept' to agree to these rules, or 'decline' to leave: ")
+	}
+}
+
+// recordRulesAccepted saves the rules version the player accepted. A
+// failure is logged only: they still play, and are asked again next login.
+// Caller must hold c.mu.
+func (c *Client) recordRulesAccepted() {
+	if err := database.AcceptRules(c.playerID, c.rulesVersion); err != nil {
+		log.Printf("Error recording rules acceptance for %s: %v", c.username, err)
+	}
+}
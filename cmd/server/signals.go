@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"mudengine/internal/database"
+)
+
+// reloadConfig re-reads the configuration file and applies the settings
+// that can change at runtime. Settings that need a restart are reported
+// but keep their current values. A failed reload leaves everything as is.
+func (s *Server) reloadConfig() {
+	current := s.currentConfig()
+
+	next, err := current.Reload()
+	if err != nil {
+		log.Printf("Config reload failed, keeping current configuration: %v", err)
+		return
+	}
+
+	// The listener, database, TLS and game ticker are set up once at startup
+	if next.ServerPort != current.ServerPort ||
+		next.DBType != current.DBType || next.DBName != current.DBName ||
+		next.DBHost != current.DBHost || next.DBPort != current.DBPort ||
+		next.DBUser != current.DBUser || next.DBPassword != current.DBPassword ||
+		next.TLSEnabled != current.TLSEnabled ||
+		next.TickIntervalSecs != current.TickIntervalSecs {
+		log.Println("Warning: server port, database, TLS and tick interval changes need a restart and were not applied")
+		next.ServerPort = current.ServerPort
+		next.DBType, next.DBName = current.DBType, current.DBName
+		next.DBHost, next.DBPort = current.DBHost, current.DBPort
+		next.DBUser, next.DBPassword = current.DBUser, current.DBPassword
+		next.TLSEnabled = current.TLSEnabled
+		next.TickIntervalSecs = current.TickIntervalSecs
+	}
+
+	s.limiter.setLimits(next.ConnRateLimit, time.Duration(next.ConnRateWindowSecs)*time.Second)
+	database.SetRoomLimits(next.RoomTitleMaxLen, next.RoomDescMaxLen)
+	s.cfg.Store(next)
+
+	log.Println("Configuration reloaded")
+}
+
+// logStats writes a snapshot of runtime statistics to the log
+func (s *Server) logStats() {
+	stats := s.collectStats()
+
+	log.Println("=== Server Stats ===")
+	log.Printf("Uptime: %s", time.Duration(stats.UptimeSecs)*time.Second)
+	log.Printf("Clients: %d connected, %d authenticated", stats.ConnectedClients, stats.AuthenticatedPlayers)
+	log.Printf("Goroutines: %d", stats.Goroutines)
+	log.Printf("Memory: %s in use, %s from OS", formatBytes(stats.Memory.HeapInUseBytes), formatBytes(stats.Memory.SysBytes))
+	log.Printf("DB pool: %d open, %d in use", stats.DBPool.OpenConnections, stats.DBPool.InUse)
+	if world := stats.World; world != nil {
+		log.Printf("World: %d zones, %d rooms, %d players, %d NPCs, %d objects",
+			world.Zones, world.Rooms, world.Players, world.NPCs, world.Objects)
+	}
+	log.Println("====================")
+}
+
+// formatBytes renders a byte count in human-readable binary units
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// clientCounts returns how many clients are connected and how many of
+// them have finished logging in
+func (s *Server) clientCounts() (connected, authenticated int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for client := range s.clients {
+		client.mu.Lock()
+		if client.authState == StateAuthenticated {
+			authenticated++
+		}
+		client.mu.Unlock()
+	}
+
+	return len(s.clients), authenticated
+}
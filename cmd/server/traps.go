@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"mudengine/internal/database"
+)
+
+// applyRoomTraps is a tick handler that damages online players standing in
+// trapped rooms. A trap fires every trap_tick_interval ticks.
+func (s *Server) applyRoomTraps(tick uint64) {
+	rooms := make(map[string]*database.Room)
+
+	for _, player := range s.presence.snapshot() {
+		room, seen := rooms[player.roomID]
+		if !seen {
+			var err error
+			room, err = database.GetRoom(player.roomID)
+			if err != nil {
+				log.Printf("Error loading room %s for traps: %v", player.roomID, err)
+			}
+			rooms[player.roomID] = room
+		}
+		if room == nil || !room.HasTrap || room.TrapDamage <= 0 {
+			continue
+		}
+
+		interval := uint64(max(room.TrapTickInterval, 1))
+		if tick%interval != 0 {
+			continue
+		}
+
+		health, died, err := database.DamageEntity(player.entityID, room.TrapDamage)
+		if err != nil {
+			log.Printf("Error applying trap in room %s to %s: %v", room.ID, player.username, err)
+			continue
+		}
+
+		if died {
+			s.killByTrap(player, room)
+			continue
+		}
+
+		s.withClient(player.client, func(c *Client) {
+			c.sendMessage(fmt.Sprintf("\r\nA hidden trap strikes you for %d damage! (%d health left)\r\n> ", room.TrapDamage, health))
+		})
+	}
+}
+
+// killByTrap moves a player killed by a trap to their respawn room and
+// restores their health. Death is not a teleport, so no_teleport_out rooms
+// don't hold the body.
+func (s *Server) killByTrap(player onlinePlayer, room *database.Room) {
+	log.Printf("%s was killed by a trap in room %s", player.username, room.ID)
+
+	globalRespawn := s.currentConfig().RespawnRoomID
+	if globalRespawn == "" {
+		globalRespawn = database.StartingRoomID
+	}
+
+	respawn, err := database.GetRoomOrLimbo(database.ResolveRespawnRoom(room.ID, globalRespawn))
+	if err != nil {
+		log.Printf("Error loading respawn room for %s: %v", player.username, err)
+		return
+	}
+
+	if err := database.MoveEntity(player.entityID, respawn.ID); err != nil {
+		log.Printf("Error moving %s to respawn room: %v", player.username, err)
+		return
+	}
+	if err := database.RestoreHealth(player.entityID); err != nil {
+		log.Printf("Error restoring health for %s: %v", player.username, err)
+	}
+
+	s.withClient(player.client, func(c *Client) {
+		c.roomID = respawn.ID
+		c.savedRoomID = respawn.ID
+		s.presence.setRoom(c, respawn.ID)
+
+		c.sendMessage("\r\nA hidden trap strikes you down. You have died!\r\n\r\n")
+		c.sendMessage("You awaken, whole again, somewhere else.\r\n\r\n")
+		c.sendLook(respawn)
+		c.sendMessage("> ")
+	})
+}
+
+// withClient runs fn with the client locked, but only while the client is
+// still registered so its send channel can't be closed underneath fn.
+// Locks are taken in the usual order: s.mu, then the client's mu.
+func (s *Server) withClient(client *Client, fn func(c *Client)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.clients[client] {
+		return
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	fn(client)
+}
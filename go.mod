@@ -4,9 +4,13 @@ go 1.24.0
 
 toolchain go1.24.10
 
-require github.com/gorilla/websocket v1.5.3
-
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/mattn/go-sqlite3 v1.14.32 // indirect
+	github.com/google/uuid v1.6.0
+	github.com/gorilla/websocket v1.5.3
+	github.com/lib/pq v1.12.3
+	github.com/mattn/go-sqlite3 v1.14.32
+	github.com/pquerna/otp v1.4.0
+	golang.org/x/crypto v0.47.0
 )
+
+require github.com/boombuler/barcode v1.0.1-0.20190219062509-6c824513bacc // indirect
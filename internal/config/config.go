@@ -34,65 +34,142 @@ type Config struct {
 	RedisDB      int
 
 	// Server behavior
-	MaxPlayers          int
-	ShutdownTimeoutSecs int
-	ReconnectAttempts   int
-	SessionTimeoutMins  int
+	MaxPlayers           int
+	ShutdownTimeoutSecs  int
+	ReconnectAttempts    int
+	SessionTimeoutMins   int
+	SendQueueLimit       int    // Messages queued for a client before it is dropped as too slow
+	TickIntervalSecs     int    // Seconds between game ticks (traps, regen, ...)
+	AutosaveIntervalSecs int    // Seconds between saves of online players' state, 0 disables
+	RespawnRoomID        string // Where players recover after dying; empty means the starting room
+	StripUnknownTokens   bool   // Drop unrecognised {tokens} from room descriptions instead of showing them
+	RoomTitleMaxLen      int    // Longest room title builders may save, in characters
+	RoomDescMaxLen       int    // Longest room description builders may save, in characters
+
+	// Security settings
+	MaxLoginAttempts int // Failed password/MFA attempts before disconnect
+	MFASkewSteps     int // 30-second TOTP steps accepted either side of now
+
+	// What to do when an account logs in while already online:
+	// "takeover" disconnects the old session, "reject" refuses the new one
+	DuplicateLoginPolicy string
+
+	// Rules new and returning players must accept before playing. Empty
+	// RulesFile disables the gate; changing RulesVersion asks everyone again.
+	RulesFile    string
+	RulesVersion string
+
+	// Connection rate limiting
+	ConnRateLimit      int  // WebSocket handshakes allowed per IP per window, 0 disables
+	ConnRateWindowSecs int  // Length of the sliding window in seconds
+	TrustProxyHeaders  bool // Take the client IP from X-Forwarded-For (only behind a proxy)
+
+	// Origins allowed to open WebSocket connections; empty means same host only
+	AllowedOrigins []string
+
+	// Bearer token for the /admin HTTP endpoints; empty disables them
+	AdminAPIToken string
 
 	// TLS settings (for future use)
 	TLSEnabled  bool
 	TLSCertFile string
 	TLSKeyFile  string
+
+	// EnvFile is the file this configuration was loaded from, used by Reload
+	EnvFile string
 }
 
 // Default configuration values
 var defaultConfig = Config{
-	ServerName:          "MUD Engine",
-	ServerVersion:       "0.1.0",
-	ServerPort:          8080,
-	DBType:              "sqlite",
-	DBHost:              "localhost",
-	DBPort:              5432,
-	DBName:              "data/mud.db",
-	DBUser:              "muduser",
-	DBPassword:          "",
-	DBMaxConnections:    25,
-	DBMaxIdleConns:      5,
-	RedisEnabled:        false,
-	RedisHost:           "localhost",
-	RedisPort:           6379,
-	RedisDB:             0,
-	MaxPlayers:          100,
-	ShutdownTimeoutSecs: 30,
-	ReconnectAttempts:   5,
-	SessionTimeoutMins:  60,
-	TLSEnabled:          false,
-	TLSCertFile:         "certs/server.crt",
-	TLSKeyFile:          "certs/server.key",
+	ServerName:           "MUD Engine",
+	ServerVersion:        "0.1.0",
+	ServerPort:           8080,
+	DBType:               "sqlite",
+	DBHost:               "localhost",
+	DBPort:               5432,
+	DBName:               "data/mud.db",
+	DBUser:               "muduser",
+	DBPassword:           "",
+	DBMaxConnections:     25,
+	DBMaxIdleConns:       5,
+	RedisEnabled:         false,
+	RedisHost:            "localhost",
+	RedisPort:            6379,
+	RedisDB:              0,
+	MaxPlayers:           100,
+	ShutdownTimeoutSecs:  30,
+	ReconnectAttempts:    5,
+	SessionTimeoutMins:   60,
+	SendQueueLimit:       200,
+	TickIntervalSecs:     1,
+	AutosaveIntervalSecs: 300,
+	StripUnknownTokens:   false,
+	RoomTitleMaxLen:      80,
+	RoomDescMaxLen:       4000,
+	MaxLoginAttempts:     3,
+	MFASkewSteps:         1,
+	DuplicateLoginPolicy: "takeover",
+	ConnRateLimit:        10,
+	ConnRateWindowSecs:   60,
+	TrustProxyHeaders:    false,
+	TLSEnabled:           false,
+	TLSCertFile:          "certs/server.crt",
+	TLSKeyFile:           "certs/server.key",
 }
 
 // LoadConfig loads configuration from environment file
-// Command line flag -env can specify a custom .env file
+// Command line flag -env can specify a custom .env file, and -no-write-env
+// stops a default file being created when it is missing
+//
+// Precedence, lowest to highest: built-in defaults, the .env file, then
+// real environment variables with the same key names.
 func LoadConfig() (*Config, error) {
 	// Parse command line flags
 	envFile := flag.String("env", ".env", "Path to environment configuration file")
+	noWriteEnv := flag.Bool("no-write-env", false, "Don't create a default configuration file when it is missing")
 	flag.Parse()
 
-	log.Printf("Loading configuration from: %s", *envFile)
+	return loadConfigFile(*envFile, !*noWriteEnv)
+}
+
+// Reload reads the configuration again from the file it was loaded from,
+// without writing a default file. The receiver is left unchanged.
+func (c *Config) Reload() (*Config, error) {
+	return loadConfigFile(c.EnvFile, false)
+}
+
+// loadConfigFile loads defaults, envFile and the environment, creating a
+// default file at envFile when it is missing and writeDefault is set
+func loadConfigFile(envFile string, writeDefault bool) (*Config, error) {
+	log.Printf("Loading configuration from: %s", envFile)
 
 	// Start with default config
 	config := defaultConfig
+	config.EnvFile = envFile
+
+	// Try to load from .env file, then overlay the environment
+	fileErr := loadEnvFile(envFile, &config)
+	envCount := applyEnvOverrides(&config)
 
-	// Try to load from .env file
-	if err := loadEnvFile(*envFile, &config); err != nil {
-		if os.IsNotExist(err) {
-			log.Printf("Configuration file %s not found, creating with defaults...", *envFile)
-			if err := createDefaultEnvFile(*envFile); err != nil {
-				return nil, fmt.Errorf("failed to create default config: %w", err)
+	if fileErr != nil {
+		if !os.IsNotExist(fileErr) {
+			return nil, fmt.Errorf("failed to load config: %w", fileErr)
+		}
+
+		switch {
+		case envCount > 0:
+			// Configured through the environment (e.g. a container), don't write a file
+			log.Printf("Configuration file %s not found, using %d setting(s) from environment", envFile, envCount)
+		case !writeDefault:
+			log.Printf("Configuration file %s not found, using defaults", envFile)
+		default:
+			log.Printf("Configuration file %s not found, creating with defaults...", envFile)
+			if err := createDefaultEnvFile(envFile); err != nil {
+				// Read-only filesystems are fine, the defaults are still usable
+				log.Printf("Warning: could not create default config %s: %v", envFile, err)
+			} else {
+				log.Printf("Created default configuration file: %s", envFile)
 			}
-			log.Printf("Created default configuration file: %s", *envFile)
-		} else {
-			return nil, fmt.Errorf("failed to load config: %w", err)
 		}
 	}
 
@@ -125,19 +202,12 @@ func loadEnvFile(filename string, config *Config) error {
 			continue
 		}
 
-		// Parse key=value pairs
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			log.Printf("Warning: Invalid line %d in %s: %s", lineNum, filename, line)
+		key, value, err := parseEnvLine(line)
+		if err != nil {
+			log.Printf("Warning: Invalid line %d in %s: %v", lineNum, filename, err)
 			continue
 		}
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		// Remove quotes if present
-		value = strings.Trim(value, "\"'")
-
 		// Set configuration value
 		if err := setConfigValue(config, key, value); err != nil {
 			log.Printf("Warning: Error setting %s on line %d: %v", key, lineNum, err)
@@ -147,6 +217,80 @@ func loadEnvFile(filename string, config *Config) error {
 	return scanner.Err()
 }
 
+// parseEnvLine parses a single KEY=value line. It accepts an optional
+// "export " prefix, keeps everything inside matching quotes verbatim
+// (including '=' and '#'), and strips trailing "# comments" from
+// unquoted values.
+func parseEnvLine(line string) (string, string, error) {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+	parts := strings.SplitN(line, "=", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("expected KEY=value: %s", line)
+	}
+
+	key := strings.TrimSpace(parts[0])
+	value := strings.TrimSpace(parts[1])
+	if key == "" {
+		return "", "", fmt.Errorf("missing key: %s", line)
+	}
+
+	// Quoted value: take everything up to the matching closing quote
+	if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+		quote := value[0]
+		end := strings.IndexByte(value[1:], quote)
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated quoted value for %s", key)
+		}
+		return key, value[1 : end+1], nil
+	}
+
+	// Unquoted value: a # preceded by whitespace starts a comment
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			value = strings.TrimSpace(value[:i])
+			break
+		}
+	}
+
+	return key, value, nil
+}
+
+// configKeys lists every key understood by setConfigValue, used to look
+// up environment variable overrides
+var configKeys = []string{
+	"SERVER_NAME", "SERVER_VERSION", "SERVER_PORT",
+	"DB_TYPE", "DB_HOST", "DB_PORT", "DB_NAME", "DB_USER", "DB_PASSWORD",
+	"DB_MAX_CONNECTIONS", "DB_MAX_IDLE_CONNS",
+	"REDIS_ENABLED", "REDIS_HOST", "REDIS_PORT", "REDIS_DB",
+	"MAX_PLAYERS", "SHUTDOWN_TIMEOUT_SECS", "RECONNECT_ATTEMPTS", "SESSION_TIMEOUT_MINS",
+	"SEND_QUEUE_LIMIT", "TICK_INTERVAL_SECS", "AUTOSAVE_INTERVAL_SECS",
+	"RESPAWN_ROOM_ID", "STRIP_UNKNOWN_TOKENS", "ROOM_TITLE_MAX_LEN", "ROOM_DESC_MAX_LEN",
+	"MAX_LOGIN_ATTEMPTS", "MFA_SKEW_STEPS", "DUPLICATE_LOGIN_POLICY",
+	"RULES_FILE", "RULES_VERSION",
+	"CONN_RATE_LIMIT", "CONN_RATE_WINDOW_SECS", "TRUST_PROXY_HEADERS",
+	"ALLOWED_ORIGINS", "ADMIN_API_TOKEN",
+	"TLS_ENABLED", "TLS_CERT_FILE", "TLS_KEY_FILE",
+}
+
+// applyEnvOverrides overlays real environment variables on top of the
+// values loaded from the .env file and returns how many were applied
+func applyEnvOverrides(config *Config) int {
+	applied := 0
+	for _, key := range configKeys {
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+		if err := setConfigValue(config, key, value); err != nil {
+			log.Printf("Warning: Error setting %s from environment: %v", key, err)
+			continue
+		}
+		applied++
+	}
+	return applied
+}
+
 // setConfigValue sets a configuration value by key name
 func setConfigValue(config *Config, key, value string) error {
 	switch key {
@@ -235,6 +379,83 @@ func setConfigValue(config *Config, key, value string) error {
 			return err
 		}
 		config.SessionTimeoutMins = timeout
+	case "SEND_QUEUE_LIMIT":
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		config.SendQueueLimit = limit
+	case "TICK_INTERVAL_SECS":
+		interval, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		config.TickIntervalSecs = interval
+	case "AUTOSAVE_INTERVAL_SECS":
+		interval, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		config.AutosaveIntervalSecs = interval
+	case "RESPAWN_ROOM_ID":
+		config.RespawnRoomID = value
+	case "STRIP_UNKNOWN_TOKENS":
+		config.StripUnknownTokens = value == "true" || value == "1"
+	case "ROOM_TITLE_MAX_LEN":
+		length, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		config.RoomTitleMaxLen = length
+	case "ROOM_DESC_MAX_LEN":
+		length, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		config.RoomDescMaxLen = length
+
+	// Security settings
+	case "MAX_LOGIN_ATTEMPTS":
+		attempts, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		config.MaxLoginAttempts = attempts
+	case "MFA_SKEW_STEPS":
+		steps, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		config.MFASkewSteps = steps
+	case "DUPLICATE_LOGIN_POLICY":
+		config.DuplicateLoginPolicy = value
+	case "RULES_FILE":
+		config.RulesFile = value
+	case "RULES_VERSION":
+		config.RulesVersion = value
+	case "CONN_RATE_LIMIT":
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		config.ConnRateLimit = limit
+	case "CONN_RATE_WINDOW_SECS":
+		window, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		config.ConnRateWindowSecs = window
+	case "TRUST_PROXY_HEADERS":
+		config.TrustProxyHeaders = value == "true" || value == "1"
+	case "ALLOWED_ORIGINS":
+		config.AllowedOrigins = nil
+		for _, origin := range strings.Split(value, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				config.AllowedOrigins = append(config.AllowedOrigins, origin)
+			}
+		}
+	case "ADMIN_API_TOKEN":
+		config.AdminAPIToken = value
 
 	// TLS settings
 	case "TLS_ENABLED":
@@ -257,6 +478,7 @@ func createDefaultEnvFile(filename string) error {
 	content := `# MUD Engine Configuration File
 # This file contains bootstrap configuration for the MUD server
 # It will be automatically created with defaults if missing
+# Environment variables with the same names override values in this file
 
 # ==============================================================================
 # SERVER SETTINGS
@@ -300,6 +522,54 @@ MAX_PLAYERS=100
 SHUTDOWN_TIMEOUT_SECS=30
 RECONNECT_ATTEMPTS=5
 SESSION_TIMEOUT_MINS=60
+# Messages queued for a client that isn't reading before it is
+# disconnected, so one slow connection can't hold up the rest (1-256)
+SEND_QUEUE_LIMIT=200
+# Seconds between game ticks; trap intervals are counted in ticks
+TICK_INTERVAL_SECS=1
+# Seconds between saves of online players, bounding what a crash can lose
+# (0 disables; players are still saved on logout and shutdown)
+AUTOSAVE_INTERVAL_SECS=300
+# Room players respawn in after dying, unless their zone sets its own.
+# Leave empty to use the Starting Area's default room.
+RESPAWN_ROOM_ID=
+# Room descriptions expand {players} and {exits}. Other {tokens} are shown
+# as typed unless this is true, in which case they are removed.
+STRIP_UNKNOWN_TOKENS=false
+# Longest room title and description builders may save, in characters
+ROOM_TITLE_MAX_LEN=80
+ROOM_DESC_MAX_LEN=4000
+
+# ==============================================================================
+# SECURITY SETTINGS
+# ==============================================================================
+# Failed password/MFA attempts allowed per connection before disconnecting
+MAX_LOGIN_ATTEMPTS=3
+# TOTP time steps (30s each) accepted before/after the current one
+MFA_SKEW_STEPS=1
+# When an account logs in while already online: "takeover" disconnects
+# the old connection, "reject" turns the new one away
+DUPLICATE_LOGIN_POLICY=takeover
+# Text file of rules players must type "accept" to before playing. Bump
+# RULES_VERSION when they change to ask everyone again. Empty disables.
+RULES_FILE=
+RULES_VERSION=
+
+# WebSocket handshakes allowed per IP within the window (0 disables)
+CONN_RATE_LIMIT=10
+CONN_RATE_WINDOW_SECS=60
+
+# Only enable behind a reverse proxy that sets X-Forwarded-For
+TRUST_PROXY_HEADERS=false
+
+# Comma-separated origins allowed to open WebSocket connections, e.g.
+# https://play.example.com. Leave empty to allow only the server's own host.
+# A single * allows any origin (development only).
+ALLOWED_ORIGINS=
+
+# Bearer token for the /admin HTTP endpoints (e.g. /admin/stats).
+# Leave empty to disable them. Use a long random value.
+ADMIN_API_TOKEN=
 
 # ==============================================================================
 # TLS/SSL SETTINGS (Future Use)
@@ -345,6 +615,51 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("SHUTDOWN_TIMEOUT_SECS must be at least 5 seconds")
 	}
 
+	// 256 is the size of each client's send buffer
+	if config.SendQueueLimit < 1 || config.SendQueueLimit > 256 {
+		return fmt.Errorf("invalid SEND_QUEUE_LIMIT: must be between 1 and 256")
+	}
+
+	if config.TickIntervalSecs < 1 {
+		return fmt.Errorf("TICK_INTERVAL_SECS must be at least 1 second")
+	}
+
+	if config.RoomTitleMaxLen < 1 {
+		return fmt.Errorf("ROOM_TITLE_MAX_LEN must be at least 1")
+	}
+
+	if config.RoomDescMaxLen < 1 {
+		return fmt.Errorf("ROOM_DESC_MAX_LEN must be at least 1")
+	}
+
+	if config.AutosaveIntervalSecs < 0 {
+		return fmt.Errorf("AUTOSAVE_INTERVAL_SECS cannot be negative")
+	}
+
+	if config.MaxLoginAttempts < 1 {
+		return fmt.Errorf("MAX_LOGIN_ATTEMPTS must be at least 1")
+	}
+
+	if config.MFASkewSteps < 0 {
+		return fmt.Errorf("MFA_SKEW_STEPS cannot be negative")
+	}
+
+	if config.DuplicateLoginPolicy != "takeover" && config.DuplicateLoginPolicy != "reject" {
+		return fmt.Errorf("invalid DUPLICATE_LOGIN_POLICY: must be 'takeover' or 'reject'")
+	}
+
+	if config.RulesFile != "" && config.RulesVersion == "" {
+		return fmt.Errorf("RULES_VERSION required when RULES_FILE is set")
+	}
+
+	if config.ConnRateLimit < 0 {
+		return fmt.Errorf("CONN_RATE_LIMIT cannot be negative")
+	}
+
+	if config.ConnRateWindowSecs < 1 {
+		return fmt.Errorf("CONN_RATE_WINDOW_SECS must be at least 1 second")
+	}
+
 	return nil
 }
 
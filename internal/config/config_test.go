@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseEnvLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		key   string
+		value string
+	}{
+		{"plain", "SERVER_PORT=8080", "SERVER_PORT", "8080"},
+		{"spaces around =", "SERVER_NAME = My MUD", "SERVER_NAME", "My MUD"},
+		{"export prefix", "export DB_NAME=data/mud.db", "DB_NAME", "data/mud.db"},
+		{"empty value", "RESPAWN_ROOM_ID=", "RESPAWN_ROOM_ID", ""},
+		{"= in unquoted value", "ADMIN_API_TOKEN=abc=def==", "ADMIN_API_TOKEN", "abc=def=="},
+		{"inline comment", "SERVER_PORT=8080 # the web port", "SERVER_PORT", "8080"},
+		{"inline comment after tab", "SERVER_PORT=8080\t# the web port", "SERVER_PORT", "8080"},
+		{"# without space is kept", "DB_PASSWORD=pa#ss", "DB_PASSWORD", "pa#ss"},
+		{"double quotes", `SERVER_NAME="My MUD"`, "SERVER_NAME", "My MUD"},
+		{"single quotes", "SERVER_NAME='My MUD'", "SERVER_NAME", "My MUD"},
+		{"= inside quotes", `DB_PASSWORD="a=b=c"`, "DB_PASSWORD", "a=b=c"},
+		{"# inside quotes", `DB_PASSWORD="pa # ss"`, "DB_PASSWORD", "pa # ss"},
+		{"comment after quotes", `SERVER_NAME="My MUD" # display name`, "SERVER_NAME", "My MUD"},
+		{"export and quotes", `export SERVER_NAME="My MUD"`, "SERVER_NAME", "My MUD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, value, err := parseEnvLine(tt.line)
+			if err != nil {
+				t.Fatalf("parseEnvLine(%q) error: %v", tt.line, err)
+			}
+			if key != tt.key || value != tt.value {
+				t.Errorf("parseEnvLine(%q) = %q, %q; want %q, %q", tt.line, key, value, tt.key, tt.value)
+			}
+		})
+	}
+}
+
+func TestParseEnvLineErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"no =", "SERVER_PORT 8080"},
+		{"missing key", "=8080"},
+		{"unterminated double quote", `SERVER_NAME="My MUD`},
+		{"unterminated single quote", "SERVER_NAME='My MUD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if key, value, err := parseEnvLine(tt.line); err == nil {
+				t.Errorf("parseEnvLine(%q) = %q, %q; want an error", tt.line, key, value)
+			}
+		})
+	}
+}
+
+func TestEnvironmentOverridesFile(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "test.env")
+	content := "SERVER_PORT=8080\nSERVER_NAME=From File\n"
+	if err := os.WriteFile(envFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("SERVER_PORT", "9090")
+
+	cfg, err := loadConfigFile(envFile, false)
+	if err != nil {
+		t.Fatalf("loadConfigFile: %v", err)
+	}
+	if cfg.ServerPort != 9090 {
+		t.Errorf("ServerPort = %d, want the environment's 9090", cfg.ServerPort)
+	}
+	if cfg.ServerName != "From File" {
+		t.Errorf("ServerName = %q, want the file's %q", cfg.ServerName, "From File")
+	}
+}
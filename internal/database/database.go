@@ -1,25 +1,41 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"mudengine/internal/config"
 
+	_ "github.com/lib/pq"           // PostgreSQL driver
 	_ "github.com/mattn/go-sqlite3" // SQLite driver
 )
 
 // DB is the global database connection
 var DB *sql.DB
 
-// Initialize opens and initializes the database connection
-func Initialize(cfg *config.Config) error {
+// driver is the DB_TYPE the connection was opened with, set by Initialize.
+// It decides the placeholder style rebind produces.
+var driver string
+
+// Initialize opens and initializes the database connection. Cancelling
+// ctx aborts it between steps, or during a migration, which is rolled
+// back. On any failure the connection is closed again, so nothing is left
+// half open.
+func Initialize(ctx context.Context, cfg *config.Config) (err error) {
 	log.Println("Initializing database connection...")
 
-	var err error
+	defer func() {
+		if err != nil && DB != nil {
+			DB.Close()
+			DB = nil
+		}
+	}()
 
 	switch cfg.DBType {
 	case "sqlite":
@@ -33,9 +49,11 @@ func Initialize(cfg *config.Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
+	driver = cfg.DBType
+	SetRoomLimits(cfg.RoomTitleMaxLen, cfg.RoomDescMaxLen)
 
 	// Test the connection
-	if err := DB.Ping(); err != nil {
+	if err := DB.PingContext(ctx); err != nil {
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
@@ -45,20 +63,16 @@ func Initialize(cfg *config.Config) error {
 
 	log.Printf("Database connection established (%s)", cfg.DBType)
 
-	// Check if database needs initialization
-	needsInit, err := needsInitialization()
-	if err != nil {
-		return fmt.Errorf("failed to check initialization status: %w", err)
+	// Migrations and initial data are idempotent, so always apply them.
+	// This also repairs databases whose first initialization was interrupted.
+	if err := initializeSchema(ctx); err != nil {
+		return fmt.Errorf("failed to initialize schema: %w", err)
 	}
+	log.Println("Database schema initialized successfully")
 
-	if needsInit {
-		log.Println("Database appears to be new, initializing schema...")
-		if err := initializeSchema(); err != nil {
-			return fmt.Errorf("failed to initialize schema: %w", err)
-		}
-		log.Println("Database schema initialized successfully")
-	} else {
-		log.Println("Database schema already exists")
+	// Limbo must always exist so players in a missing room have somewhere to go
+	if err := ensureLimboRoom(); err != nil {
+		return fmt.Errorf("failed to ensure limbo room: %w", err)
 	}
 
 	return nil
@@ -74,18 +88,19 @@ func initializeSQLite(cfg *config.Config) error {
 		}
 	}
 
-	// Open database connection
+	// Fail fast on permission problems instead of on the first write
+	if err := checkDatabasePath(cfg.DBName); err != nil {
+		return err
+	}
+
+	// Open database connection. Foreign keys are enabled in the DSN so
+	// every pooled connection has them; a PRAGMA only reaches one.
 	var err error
-	DB, err = sql.Open("sqlite3", cfg.DBName)
+	DB, err = sql.Open("sqlite3", cfg.DBName+"?_foreign_keys=on")
 	if err != nil {
 		return fmt.Errorf("failed to open SQLite database: %w", err)
 	}
 
-	// Enable foreign keys for SQLite
-	if _, err := DB.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		return fmt.Errorf("failed to enable foreign keys: %w", err)
-	}
-
 	// Set SQLite performance options
 	if _, err := DB.Exec("PRAGMA journal_mode = WAL"); err != nil {
 		log.Printf("Warning: failed to set WAL mode: %v", err)
@@ -94,11 +109,37 @@ func initializeSQLite(cfg *config.Config) error {
 	return nil
 }
 
+// checkDatabasePath verifies the SQLite file's directory is writable and,
+// if the file already exists, that it can be opened for reading and writing
+func checkDatabasePath(dbPath string) error {
+	absPath, err := filepath.Abs(dbPath)
+	if err != nil {
+		absPath = dbPath
+	}
+	absDir := filepath.Dir(absPath)
+
+	// The directory must accept new files (SQLite also creates -wal/-shm files there)
+	probe, err := os.CreateTemp(absDir, ".mud-write-check-*")
+	if err != nil {
+		return fmt.Errorf("cannot write to %s; check permissions: %w", absDir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	file, err := os.OpenFile(absPath, os.O_RDWR, 0)
+	if err == nil {
+		file.Close()
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("cannot read and write database file %s; check permissions: %w", absPath, err)
+	}
+
+	return nil
+}
+
 // initializePostgreSQL sets up PostgreSQL database connection
 func initializePostgreSQL(cfg *config.Config) error {
-	// TODO: Implement PostgreSQL connection
-	// This is a placeholder for when we migrate to PostgreSQL
-
 	connStr := cfg.GetConnectionString()
 	var err error
 	DB, err = sql.Open("postgres", connStr)
@@ -109,161 +150,63 @@ func initializePostgreSQL(cfg *config.Config) error {
 	return nil
 }
 
-// needsInitialization checks if the database schema needs to be created
-func needsInitialization() (bool, error) {
-	// Check if the zones table exists
-	var tableName string
-	query := `
-		SELECT name FROM sqlite_master 
-		WHERE type='table' AND name='zones'
-	`
+// rebind rewrites a query's ? placeholders to $1, $2, ... when connected
+// to PostgreSQL, which doesn't accept ?. Queries are written with ? and
+// passed through rebind at every Exec/Query call. Question marks inside
+// single-quoted SQL strings are left alone.
+func rebind(query string) string {
+	if driver != "postgres" {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted:
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// rowExists reports whether table has a row where column equals value.
+// table and column must be trusted identifiers, never user input.
+func rowExists(table, column string, value any) (bool, error) {
+	var found int
+	query := fmt.Sprintf("SELECT 1 FROM %s WHERE %s = ?", table, column)
 
-	err := DB.QueryRow(query).Scan(&tableName)
+	err := DB.QueryRow(rebind(query), value).Scan(&found)
 	if err == sql.ErrNoRows {
-		// Table doesn't exist, needs initialization
-		return true, nil
+		return false, nil
 	}
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("failed to check %s: %w", table, err)
 	}
 
-	// Table exists
-	return false, nil
+	return true, nil
 }
 
-// initializeSchema creates all database tables
-func initializeSchema() error {
-	// Read and execute the schema SQL
-	// For now, we'll define it inline. Later we can move to a separate file.
-
-	schema := `
--- Zones/Areas/Districts
-CREATE TABLE IF NOT EXISTS zones (
-    id TEXT PRIMARY KEY,
-    name TEXT NOT NULL,
-    description TEXT,
-    theme TEXT,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Rooms
-CREATE TABLE IF NOT EXISTS rooms (
-    id TEXT PRIMARY KEY,
-    zone_id TEXT NOT NULL,
-    title TEXT NOT NULL,
-    description TEXT NOT NULL,
-    terrain TEXT DEFAULT 'indoor',
-    darkness INTEGER DEFAULT 0,
-    blocks_magic BOOLEAN DEFAULT 0,
-    restricts_movement BOOLEAN DEFAULT 0,
-    no_teleport_in BOOLEAN DEFAULT 0,
-    no_teleport_out BOOLEAN DEFAULT 0,
-    has_trap BOOLEAN DEFAULT 0,
-    trap_damage INTEGER DEFAULT 0,
-    trap_tick_interval INTEGER DEFAULT 0,
-    status TEXT DEFAULT '',
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (zone_id) REFERENCES zones(id)
-);
-
--- Exits
-CREATE TABLE IF NOT EXISTS exits (
-    id TEXT PRIMARY KEY,
-    from_room_id TEXT NOT NULL,
-    to_room_id TEXT NOT NULL,
-    keywords TEXT NOT NULL,
-    description TEXT,
-    is_hidden BOOLEAN DEFAULT 0,
-    is_obvious BOOLEAN DEFAULT 1,
-    allow_look_through BOOLEAN DEFAULT 1,
-    is_open BOOLEAN DEFAULT 1,
-    is_locked BOOLEAN DEFAULT 0,
-    requires_item_id TEXT,
-    FOREIGN KEY (from_room_id) REFERENCES rooms(id),
-    FOREIGN KEY (to_room_id) REFERENCES rooms(id),
-    FOREIGN KEY (requires_item_id) REFERENCES game_objects(id)
-);
-
--- Game Objects
-CREATE TABLE IF NOT EXISTS game_objects (
-    id TEXT PRIMARY KEY,
-    name TEXT NOT NULL,
-    description TEXT NOT NULL,
-    container_id TEXT,
-    container_type TEXT,
-    object_type TEXT NOT NULL,
-    is_obvious BOOLEAN DEFAULT 1,
-    is_hidden BOOLEAN DEFAULT 0,
-    can_pick_up BOOLEAN DEFAULT 1,
-    is_readable BOOLEAN DEFAULT 0,
-    read_text TEXT,
-    is_container BOOLEAN DEFAULT 0,
-    capacity REAL DEFAULT 0.0,
-    is_open BOOLEAN DEFAULT 1,
-    weight REAL DEFAULT 0.0,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Entities
-CREATE TABLE IF NOT EXISTS entities (
-    id TEXT PRIMARY KEY,
-    name TEXT NOT NULL,
-    description TEXT NOT NULL,
-    room_id TEXT NOT NULL,
-    entity_type TEXT NOT NULL,
-    darkvision INTEGER DEFAULT 0,
-    is_hidden BOOLEAN DEFAULT 0,
-    health INTEGER DEFAULT 100,
-    max_health INTEGER DEFAULT 100,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (room_id) REFERENCES rooms(id)
-);
-
--- Players
-CREATE TABLE IF NOT EXISTS players (
-    id TEXT PRIMARY KEY,
-    entity_id TEXT NOT NULL UNIQUE,
-    username TEXT UNIQUE NOT NULL,
-    password_hash TEXT,
-    mfa_secret TEXT,
-    last_login TIMESTAMP,
-    last_logout TIMESTAMP,
-    is_builder BOOLEAN DEFAULT 0,
-    is_admin BOOLEAN DEFAULT 0,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (entity_id) REFERENCES entities(id)
-);
-
--- NPCs
-CREATE TABLE IF NOT EXISTS npcs (
-    id TEXT PRIMARY KEY,
-    entity_id TEXT NOT NULL UNIQUE,
-    is_aggressive BOOLEAN DEFAULT 0,
-    is_merchant BOOLEAN DEFAULT 0,
-    greeting TEXT,
-    FOREIGN KEY (entity_id) REFERENCES entities(id)
-);
-
--- Indexes
-CREATE INDEX IF NOT EXISTS idx_objects_container ON game_objects(container_id);
-CREATE INDEX IF NOT EXISTS idx_objects_container_type ON game_objects(container_type);
-CREATE INDEX IF NOT EXISTS idx_exits_from_room ON exits(from_room_id);
-CREATE INDEX IF NOT EXISTS idx_exits_keywords ON exits(keywords);
-CREATE INDEX IF NOT EXISTS idx_rooms_zone ON rooms(zone_id);
-CREATE INDEX IF NOT EXISTS idx_entities_room ON entities(room_id);
-CREATE INDEX IF NOT EXISTS idx_players_username ON players(username);
-`
-
-	// Execute the schema
-	if _, err := DB.Exec(schema); err != nil {
-		return fmt.Errorf("failed to create schema: %w", err)
-	}
-
-	log.Println("Database tables created successfully")
+// initializeSchema brings the tables up to date and adds the initial data
+func initializeSchema(ctx context.Context) error {
+	if err := migrate(ctx); err != nil {
+		return err
+	}
+
+	log.Println("Database tables ready")
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	// Insert initial data
 	if err := insertInitialData(); err != nil {
@@ -273,24 +216,25 @@ CREATE INDEX IF NOT EXISTS idx_players_username ON players(username);
 	return nil
 }
 
-// insertInitialData adds the default zones and rooms
+// insertInitialData adds the default zones and rooms. Rows with fixed IDs
+// use ON CONFLICT DO NOTHING, so re-running it is a safe no-op.
 func insertInitialData() error {
-	log.Println("Inserting initial data...")
-
 	// Insert Staff Area zone
-	_, err := DB.Exec(`
+	_, err := DB.Exec(rebind(`
 		INSERT INTO zones (id, name, description, theme) 
 		VALUES (?, ?, ?, ?)
-	`, "00000000-0000-0000-0000-000000000001", "Staff Area", "Administrative and building zone", "meta")
+		ON CONFLICT(id) DO NOTHING
+	`), "00000000-0000-0000-0000-000000000001", "Staff Area", "Administrative and building zone", "meta")
 	if err != nil {
 		return fmt.Errorf("failed to insert staff zone: %w", err)
 	}
 
 	// Insert Builder Room (Room 0)
-	_, err = DB.Exec(`
+	_, err = DB.Exec(rebind(`
 		INSERT INTO rooms (id, zone_id, title, description, darkness, status)
 		VALUES (?, ?, ?, ?, ?, ?)
-	`,
+		ON CONFLICT(id) DO NOTHING
+	`),
 		"00000000-0000-0000-0000-000000000000",
 		"00000000-0000-0000-0000-000000000001",
 		"The Builder Break Room",
@@ -302,18 +246,153 @@ func insertInitialData() error {
 	}
 
 	// Insert Starting Area zone
-	_, err = DB.Exec(`
+	_, err = DB.Exec(rebind(`
 		INSERT INTO zones (id, name, description, theme)
 		VALUES (?, ?, ?, ?)
-	`, "10000000-0000-0000-0000-000000000001", "Starting Area", "Where new players begin their journey", "generic")
+		ON CONFLICT(id) DO NOTHING
+	`), "10000000-0000-0000-0000-000000000001", "Starting Area", "Where new players begin their journey", "generic")
 	if err != nil {
 		return fmt.Errorf("failed to insert starting zone: %w", err)
 	}
 
-	log.Println("Initial data inserted successfully")
+	// Insert the Starting Area's default room, where new players appear
+	_, err = DB.Exec(rebind(`
+		INSERT INTO rooms (id, zone_id, title, description, terrain, darkness, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO NOTHING
+	`),
+		StartingRoomID,
+		"10000000-0000-0000-0000-000000000001",
+		"The Town Square",
+		"You stand in the bustling town square. A large fountain dominates the center, with merchants hawking their wares around its edge.",
+		"outdoor",
+		0,
+		"")
+	if err != nil {
+		return fmt.Errorf("failed to insert starting room: %w", err)
+	}
+
+	return insertDefaultSocials()
+}
+
+// insertDefaultSocials adds any default socials that are missing
+func insertDefaultSocials() error {
+	for _, social := range defaultSocials {
+		exists, err := rowExists("socials", "verb", social.Verb)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		if err := CreateSocial(social); err != nil {
+			return fmt.Errorf("failed to insert social %s: %w", social.Verb, err)
+		}
+	}
+
+	return nil
+}
+
+// LimboRoomID is the fallback room for players whose room can't be loaded
+const LimboRoomID = "00000000-0000-0000-0000-000000000002"
+
+// StartingRoomID is the Starting Area room new players are placed in
+const StartingRoomID = "10000000-0000-0000-0000-000000000002"
+
+// limboExitID is the exit leading from Limbo back to the starting room
+const limboExitID = "00000000-0000-0000-0000-000000000003"
+
+// ensureLimboRoom creates the Limbo room in the Staff Area, and its exit
+// back to the starting room, if either is missing
+func ensureLimboRoom() error {
+	result, err := DB.Exec(rebind(`
+		INSERT INTO rooms (id, zone_id, title, description, darkness, status)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO NOTHING
+	`),
+		LimboRoomID,
+		"00000000-0000-0000-0000-000000000001",
+		"Limbo",
+		"You find yourself in a formless void. Shapes drift at the edge of your vision, never quite resolving into anything solid.",
+		0,
+		"")
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err == nil && rowsAffected > 0 {
+		log.Println("Limbo room was missing, created it")
+	}
+
+	return ensureLimboExit()
+}
+
+// ensureLimboExit creates the exit out of Limbo to the starting room, so
+// players who land there aren't stuck. It is skipped with a warning if
+// the starting room itself is gone.
+func ensureLimboExit() error {
+	exists, err := rowExists("rooms", "id", StartingRoomID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		log.Printf("Warning: starting room %s is missing, Limbo has no way out", StartingRoomID)
+		return nil
+	}
+
+	result, err := DB.Exec(rebind(`
+		INSERT INTO exits (id, from_room_id, to_room_id, keywords, description, is_hidden, is_obvious, is_open)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO NOTHING
+	`),
+		limboExitID,
+		LimboRoomID,
+		StartingRoomID,
+		`["out","start"]`,
+		"A faint light marks the way back to the world.",
+		false,
+		true,
+		true)
+	if err != nil {
+		return fmt.Errorf("failed to create limbo exit: %w", err)
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err == nil && rowsAffected > 0 {
+		log.Println("Limbo exit was missing, created it")
+	}
+
 	return nil
 }
 
+// WorldStats holds row counts for the main world tables
+type WorldStats struct {
+	Zones   int `json:"zones"`
+	Rooms   int `json:"rooms"`
+	Players int `json:"players"`
+	NPCs    int `json:"npcs"`
+	Objects int `json:"objects"`
+}
+
+// GetWorldStats counts the rows in the main world tables
+func GetWorldStats() (*WorldStats, error) {
+	stats := &WorldStats{}
+
+	err := DB.QueryRow(rebind(`
+		SELECT
+			(SELECT COUNT(*) FROM zones),
+			(SELECT COUNT(*) FROM rooms),
+			(SELECT COUNT(*) FROM players),
+			(SELECT COUNT(*) FROM npcs),
+			(SELECT COUNT(*) FROM game_objects)
+	`)).Scan(&stats.Zones, &stats.Rooms, &stats.Players, &stats.NPCs, &stats.Objects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get world stats: %w", err)
+	}
+
+	return stats, nil
+}
+
 // Close closes the database connection
 func Close() error {
 	if DB != nil {
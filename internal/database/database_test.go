@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"mudengine/internal/config"
+)
+
+// openTestDB initializes a fresh SQLite database in a temporary directory,
+// closed again when the test ends
+func openTestDB(t *testing.T) {
+	t.Helper()
+
+	cfg := &config.Config{
+		DBType:           "sqlite",
+		DBName:           filepath.Join(t.TempDir(), "test.db"),
+		DBMaxConnections: 4,
+		DBMaxIdleConns:   4,
+	}
+	if err := Initialize(context.Background(), cfg); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	t.Cleanup(func() {
+		Close()
+		DB = nil
+	})
+}
+
+func TestInitializeTwice(t *testing.T) {
+	cfg := &config.Config{
+		DBType:           "sqlite",
+		DBName:           filepath.Join(t.TempDir(), "test.db"),
+		DBMaxConnections: 4,
+		DBMaxIdleConns:   4,
+	}
+
+	counts := func() map[string]int {
+		t.Helper()
+
+		n := make(map[string]int)
+		for _, table := range []string{"zones", "rooms", "exits", "socials", "schema_migrations"} {
+			var count int
+			if err := DB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+				t.Fatalf("counting %s: %v", table, err)
+			}
+			n[table] = count
+		}
+		return n
+	}
+
+	if err := Initialize(context.Background(), cfg); err != nil {
+		t.Fatalf("first Initialize: %v", err)
+	}
+	first := counts()
+	Close()
+
+	if err := Initialize(context.Background(), cfg); err != nil {
+		t.Fatalf("second Initialize: %v", err)
+	}
+	t.Cleanup(func() {
+		Close()
+		DB = nil
+	})
+	second := counts()
+
+	for table, n := range first {
+		if n == 0 {
+			t.Errorf("%s is empty after the first Initialize", table)
+		}
+		if second[table] != n {
+			t.Errorf("%s has %d rows after the second Initialize, want %d", table, second[table], n)
+		}
+	}
+}
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		query  string
+		want   string
+	}{
+		{"sqlite unchanged", "sqlite", "SELECT * FROM rooms WHERE id = ? AND zone_id = ?", "SELECT * FROM rooms WHERE id = ? AND zone_id = ?"},
+		{"postgres numbered", "postgres", "SELECT * FROM rooms WHERE id = ? AND zone_id = ?", "SELECT * FROM rooms WHERE id = $1 AND zone_id = $2"},
+		{"postgres no placeholders", "postgres", "SELECT COUNT(*) FROM rooms", "SELECT COUNT(*) FROM rooms"},
+		{"postgres quoted question mark", "postgres", "UPDATE rooms SET title = 'Who?' WHERE id = ?", "UPDATE rooms SET title = 'Who?' WHERE id = $1"},
+		{"postgres escaped quote", "postgres", "SELECT 'it''s ?', ? FROM rooms", "SELECT 'it''s ?', $1 FROM rooms"},
+	}
+
+	prev := driver
+	t.Cleanup(func() { driver = prev })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver = tt.driver
+			if got := rebind(tt.query); got != tt.want {
+				t.Errorf("rebind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entity types
+const (
+	EntityTypePlayer = "player"
+	EntityTypeNPC    = "npc"
+)
+
+// Entity represents anything alive in the world: players, NPCs and mobs
+type Entity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	RoomID      string `json:"room_id"`
+	EntityType  string `json:"entity_type"`
+	Darkvision  int    `json:"darkvision"`
+	IsHidden    bool   `json:"is_hidden"`
+
+	// Vitals
+	Health    int `json:"health"`
+	MaxHealth int `json:"max_health"`
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// entityColumns lists the entity columns in the order scanEntity expects
+const entityColumns = `
+			id, name, description, room_id, entity_type,
+			darkvision, is_hidden, health, max_health,
+			created_at, updated_at`
+
+// scanEntity scans a single entity row selected with entityColumns
+func scanEntity(row rowScanner) (*Entity, error) {
+	entity := &Entity{}
+
+	err := row.Scan(
+		&entity.ID, &entity.Name, &entity.Description, &entity.RoomID, &entity.EntityType,
+		&entity.Darkvision, &entity.IsHidden, &entity.Health, &entity.MaxHealth,
+		&entity.CreatedAt, &entity.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// CreateEntity creates a new entity
+func CreateEntity(entity *Entity) error {
+	return insertEntity(DB, entity)
+}
+
+// insertEntity inserts an entity using db, which may be a transaction
+func insertEntity(db execer, entity *Entity) error {
+	// Generate UUID if not provided
+	if entity.ID == "" {
+		entity.ID = uuid.New().String()
+	}
+
+	// Set timestamps
+	now := time.Now()
+	entity.CreatedAt = now
+	entity.UpdatedAt = now
+
+	query := `
+		INSERT INTO entities (
+			id, name, description, room_id, entity_type,
+			darkvision, is_hidden, health, max_health,
+			created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := db.Exec(rebind(query),
+		entity.ID, entity.Name, entity.Description, entity.RoomID, entity.EntityType,
+		entity.Darkvision, entity.IsHidden, entity.Health, entity.MaxHealth,
+		entity.CreatedAt, entity.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create entity: %w", err)
+	}
+
+	return nil
+}
+
+// GetEntity retrieves an entity by ID
+func GetEntity(id string) (*Entity, error) {
+	query := "SELECT" + entityColumns + `
+		FROM entities
+		WHERE id = ?
+	`
+
+	entity, err := scanEntity(DB.QueryRow(rebind(query), id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("entity not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entity: %w", err)
+	}
+
+	return entity, nil
+}
+
+// GetEntitiesByRoom retrieves all entities in a room
+func GetEntitiesByRoom(roomID string) ([]*Entity, error) {
+	query := "SELECT" + entityColumns + `
+		FROM entities
+		WHERE room_id = ?
+		ORDER BY name
+	`
+
+	rows, err := DB.Query(rebind(query), roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entities: %w", err)
+	}
+	defer rows.Close()
+
+	var entities []*Entity
+	for rows.Next() {
+		entity, err := scanEntity(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan entity: %w", err)
+		}
+		entities = append(entities, entity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read entities: %w", err)
+	}
+
+	return entities, nil
+}
+
+// UpdateEntity updates an existing entity
+func UpdateEntity(entity *Entity) error {
+	return updateEntity(DB, entity)
+}
+
+// updateEntity updates an entity using db, which may be a transaction
+func updateEntity(db execer, entity *Entity) error {
+	entity.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE entities SET
+			name = ?, description = ?, room_id = ?, entity_type = ?,
+			darkvision = ?, is_hidden = ?, health = ?, max_health = ?,
+			updated_at = ?
+		WHERE id = ?
+	`
+
+	result, err := db.Exec(rebind(query),
+		entity.Name, entity.Description, entity.RoomID, entity.EntityType,
+		entity.Darkvision, entity.IsHidden, entity.Health, entity.MaxHealth,
+		entity.UpdatedAt, entity.ID,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update entity: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("entity not found: %s", entity.ID)
+	}
+
+	return nil
+}
+
+// MoveEntity moves an entity to another room
+func MoveEntity(id, roomID string) error {
+	result, err := DB.Exec(
+		rebind("UPDATE entities SET room_id = ?, updated_at = ? WHERE id = ?"),
+		roomID, time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to move entity: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("entity not found: %s", id)
+	}
+
+	return nil
+}
+
+// clampedHealth is the SQL for health plus a delta, kept within
+// [0, max_health]. It uses CASE because SQLite and PostgreSQL name their
+// scalar min and max functions differently. The delta is bound three times.
+const clampedHealth = `CASE
+			WHEN health + ? < 0 THEN 0
+			WHEN health + ? > max_health THEN max_health
+			ELSE health + ?
+		END`
+
+// DamageEntity lowers an entity's health by amount, never below zero, and
+// reports whether this damage killed it. The clamp happens inside a single
+// UPDATE so concurrent damage can't be lost to a read-modify-write race.
+//
+// An entity already at zero is left alone and not reported dead again, so
+// two hits landing together only run one death.
+func DamageEntity(id string, amount int) (newHealth int, died bool, err error) {
+	if amount < 0 {
+		return 0, false, fmt.Errorf("damage amount cannot be negative: %d", amount)
+	}
+
+	delta := -amount
+	err = DB.QueryRow(rebind(`
+		UPDATE entities SET health = `+clampedHealth+`, updated_at = ?
+		WHERE id = ? AND health > 0
+		RETURNING health
+	`), delta, delta, delta, time.Now(), id).Scan(&newHealth)
+	if err == sql.ErrNoRows {
+		return alreadyDeadHealth(id)
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to damage entity: %w", err)
+	}
+
+	return newHealth, newHealth == 0, nil
+}
+
+// alreadyDeadHealth handles a DamageEntity that updated nothing: either
+// the entity doesn't exist, or it was already at zero health
+func alreadyDeadHealth(id string) (int, bool, error) {
+	var health int
+	err := DB.QueryRow(rebind("SELECT health FROM entities WHERE id = ?"), id).Scan(&health)
+	if err == sql.ErrNoRows {
+		return 0, false, fmt.Errorf("entity not found: %s", id)
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to damage entity: %w", err)
+	}
+
+	return health, false, nil
+}
+
+// HealEntity raises an entity's health by amount, never above max_health.
+// Like DamageEntity, the clamp is applied atomically in SQL.
+func HealEntity(id string, amount int) (newHealth int, err error) {
+	if amount < 0 {
+		return 0, fmt.Errorf("heal amount cannot be negative: %d", amount)
+	}
+
+	err = DB.QueryRow(rebind(`
+		UPDATE entities SET health = `+clampedHealth+`, updated_at = ?
+		WHERE id = ?
+		RETURNING health
+	`), amount, amount, amount, time.Now(), id).Scan(&newHealth)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("entity not found: %s", id)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to heal entity: %w", err)
+	}
+
+	return newHealth, nil
+}
+
+// RestoreHealth sets an entity's health back to its max_health
+func RestoreHealth(id string) error {
+	result, err := DB.Exec(
+		rebind("UPDATE entities SET health = max_health, updated_at = ? WHERE id = ?"),
+		time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to restore health: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("entity not found: %s", id)
+	}
+
+	return nil
+}
+
+// DeleteEntity deletes an entity from the database
+func DeleteEntity(id string) error {
+	result, err := DB.Exec(rebind("DELETE FROM entities WHERE id = ?"), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete entity: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("entity not found: %s", id)
+	}
+
+	return nil
+}
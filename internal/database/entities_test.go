@@ -0,0 +1,129 @@
+package database
+
+import "testing"
+
+// createTestEntity creates an entity with 100 health in a room
+func createTestEntity(t *testing.T, name string, room *Room) *Entity {
+	t.Helper()
+
+	entity := &Entity{
+		Name:        name,
+		Description: "An entity made for testing.",
+		RoomID:      room.ID,
+		EntityType:  EntityTypeNPC,
+		Health:      100,
+		MaxHealth:   100,
+	}
+	if err := CreateEntity(entity); err != nil {
+		t.Fatalf("CreateEntity(%q): %v", name, err)
+	}
+
+	return entity
+}
+
+func TestGetEntitiesByRoom(t *testing.T) {
+	openTestDB(t)
+
+	tavern := createTestRoom(t, "Tavern")
+	cellar := createTestRoom(t, "Cellar")
+	createTestEntity(t, "the innkeeper", tavern)
+	createTestEntity(t, "a drunk patron", tavern)
+	rat := createTestEntity(t, "a cellar rat", cellar)
+
+	entities, err := GetEntitiesByRoom(tavern.ID)
+	if err != nil {
+		t.Fatalf("GetEntitiesByRoom: %v", err)
+	}
+	var names []string
+	for _, entity := range entities {
+		names = append(names, entity.Name)
+	}
+	if len(names) != 2 || names[0] != "a drunk patron" || names[1] != "the innkeeper" {
+		t.Errorf("tavern holds %v, want [a drunk patron the innkeeper]", names)
+	}
+
+	if err := MoveEntity(rat.ID, tavern.ID); err != nil {
+		t.Fatalf("MoveEntity: %v", err)
+	}
+	if entities, err := GetEntitiesByRoom(cellar.ID); err != nil || len(entities) != 0 {
+		t.Errorf("cellar holds %d entities after the move (err %v), want none", len(entities), err)
+	}
+	if entities, err := GetEntitiesByRoom(tavern.ID); err != nil || len(entities) != 3 {
+		t.Errorf("tavern holds %d entities after the move (err %v), want 3", len(entities), err)
+	}
+}
+
+func TestUpdateEntityHealth(t *testing.T) {
+	openTestDB(t)
+
+	room := createTestRoom(t, "Arena")
+	entity := createTestEntity(t, "a gladiator", room)
+
+	entity.Health = 40
+	entity.MaxHealth = 120
+	if err := UpdateEntity(entity); err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+
+	got, err := GetEntity(entity.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got.Health != 40 || got.MaxHealth != 120 {
+		t.Errorf("health = %d/%d, want 40/120", got.Health, got.MaxHealth)
+	}
+
+	if err := UpdateEntity(&Entity{ID: "no-such-entity"}); err == nil {
+		t.Error("UpdateEntity of a missing entity succeeded")
+	}
+
+	if err := DeleteEntity(entity.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	if _, err := GetEntity(entity.ID); err == nil {
+		t.Error("GetEntity found the entity after it was deleted")
+	}
+}
+
+func TestDamageAndHealClamp(t *testing.T) {
+	openTestDB(t)
+
+	room := createTestRoom(t, "Arena")
+	entity := createTestEntity(t, "a gladiator", room)
+
+	health, died, err := DamageEntity(entity.ID, 30)
+	if err != nil || health != 70 || died {
+		t.Errorf("DamageEntity(30) = %d, %v, %v; want 70, false, nil", health, died, err)
+	}
+
+	health, err = HealEntity(entity.ID, 500)
+	if err != nil || health != 100 {
+		t.Errorf("HealEntity(500) = %d, %v; want 100 (max health), nil", health, err)
+	}
+
+	health, died, err = DamageEntity(entity.ID, 250)
+	if err != nil || health != 0 || !died {
+		t.Errorf("DamageEntity(250) = %d, %v, %v; want 0, true, nil", health, died, err)
+	}
+
+	if _, _, err := DamageEntity("no-such-entity", 1); err == nil {
+		t.Error("DamageEntity of a missing entity succeeded")
+	}
+}
+
+func TestDamageDeadEntity(t *testing.T) {
+	openTestDB(t)
+
+	room := createTestRoom(t, "Arena")
+	entity := createTestEntity(t, "a gladiator", room)
+
+	if _, died, err := DamageEntity(entity.ID, 100); err != nil || !died {
+		t.Fatalf("killing DamageEntity = %v, %v; want true, nil", died, err)
+	}
+
+	// A second hit on the body must not run another death
+	health, died, err := DamageEntity(entity.ID, 10)
+	if err != nil || health != 0 || died {
+		t.Errorf("DamageEntity on a dead entity = %d, %v, %v; want 0, false, nil", health, died, err)
+	}
+}
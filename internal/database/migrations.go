@@ -0,0 +1,330 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// migration is one step in the schema's history. Its up function runs
+// inside a transaction along with recording the new version, so a
+// migration is either applied completely or not at all.
+type migration struct {
+	version     int
+	description string
+	up          func(tx *sql.Tx) error
+}
+
+// migrations lists every schema change in the order they apply. Append new
+// migrations with the next version number; never edit or reorder applied
+// ones, since deployed databases have already run them.
+var migrations = []migration{
+	{1, "initial schema", execMigration(initialSchema)},
+	{2, "player rules acceptance", execMigration(`
+ALTER TABLE players ADD COLUMN rules_version TEXT NOT NULL DEFAULT '';
+ALTER TABLE players ADD COLUMN rules_accepted_at TIMESTAMP;
+`)},
+	{3, "zone respawn rooms", func(tx *sql.Tx) error {
+		return addColumn(tx, "zones", "respawn_room_id", "TEXT")
+	}},
+	{4, "exits that consume their key", func(tx *sql.Tx) error {
+		return addColumn(tx, "exits", "consumes_key", "BOOLEAN DEFAULT FALSE")
+	}},
+	{5, "object keywords", addObjectKeywords},
+	// Fails if existing usernames differ only in case; rename one first
+	{6, "case-insensitive usernames", execMigration(`
+CREATE UNIQUE INDEX IF NOT EXISTS idx_players_username_lower ON players(lower(username));
+`)},
+	{7, "room and zone ambiance", func(tx *sql.Tx) error {
+		if err := addColumn(tx, "rooms", "ambiance", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		return addColumn(tx, "zones", "ambiance", "TEXT NOT NULL DEFAULT ''")
+	}},
+	{8, "socials", execMigration(`
+CREATE TABLE IF NOT EXISTS socials (
+    verb TEXT PRIMARY KEY,
+    no_target_self TEXT NOT NULL,
+    no_target_room TEXT NOT NULL,
+    target_self TEXT NOT NULL,
+    target_victim TEXT NOT NULL,
+    target_room TEXT NOT NULL
+);
+`)},
+	{9, "incoming exits index", execMigration(`
+CREATE INDEX IF NOT EXISTS idx_exits_to_room ON exits(to_room_id);
+`)},
+}
+
+// execMigration returns a migration step that runs the given DDL
+func execMigration(ddl string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(ddl)
+		return err
+	}
+}
+
+// addObjectKeywords adds the keywords column to game_objects and fills it
+// in for existing objects from their names
+func addObjectKeywords(tx *sql.Tx) error {
+	if err := addColumn(tx, "game_objects", "keywords", "TEXT NOT NULL DEFAULT '[]'"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_objects_keywords ON game_objects(keywords)"); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query("SELECT id, name FROM game_objects WHERE keywords = '[]'")
+	if err != nil {
+		return err
+	}
+	names := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		names[id] = name
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Updated once the rows are closed, since the transaction holds a single connection
+	for id, name := range names {
+		keywordsJSON, err := json.Marshal(DeriveKeywords(name))
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(rebind("UPDATE game_objects SET keywords = ? WHERE id = ?"), string(keywordsJSON), id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addColumn adds a column to table unless it is already there. Some
+// columns were once part of the initial schema, so databases created in
+// that window have them and plain ALTER TABLE would fail on them.
+func addColumn(tx *sql.Tx, table, column, definition string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// columnExists reports whether table has the named column.
+// table and column must be trusted identifiers, never user input.
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	query := "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?"
+	if driver == "postgres" {
+		query = "SELECT COUNT(*) FROM information_schema.columns WHERE table_name = ? AND column_name = ?"
+	}
+
+	var n int
+	if err := tx.QueryRow(rebind(query), table, column).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to check for column %s.%s: %w", table, column, err)
+	}
+
+	return n > 0, nil
+}
+
+// migrate applies every migration newer than the version recorded in
+// schema_migrations, oldest first, stopping if ctx is cancelled
+func migrate(ctx context.Context) error {
+	_, err := DB.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    description TEXT NOT NULL,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	err = DB.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, m); err != nil {
+			return err
+		}
+		log.Printf("Applied schema migration %d: %s", m.version, m.description)
+	}
+
+	return nil
+}
+
+// applyMigration runs one migration and records it in a single transaction
+func applyMigration(ctx context.Context, m migration) error {
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.version, err)
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return fmt.Errorf("failed to apply migration %d (%s): %w", m.version, m.description, err)
+	}
+
+	_, err = tx.Exec(
+		rebind("INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)"),
+		m.version, m.description, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.version, err)
+	}
+
+	return nil
+}
+
+// initialSchema is migration 1, the tables as they stood before migrations
+// were introduced. It uses IF NOT EXISTS throughout so databases created
+// before then skip the tables they already have; the later migrations
+// then bring them up to date like any other database.
+//
+// It must stay valid for both SQLite and PostgreSQL: boolean defaults
+// are written TRUE/FALSE, which SQLite stores as 1/0.
+const initialSchema = `
+-- Zones/Areas/Districts
+CREATE TABLE IF NOT EXISTS zones (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT,
+    theme TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Rooms
+CREATE TABLE IF NOT EXISTS rooms (
+    id TEXT PRIMARY KEY,
+    zone_id TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    terrain TEXT DEFAULT 'indoor',
+    darkness INTEGER DEFAULT 0,
+    blocks_magic BOOLEAN DEFAULT FALSE,
+    restricts_movement BOOLEAN DEFAULT FALSE,
+    no_teleport_in BOOLEAN DEFAULT FALSE,
+    no_teleport_out BOOLEAN DEFAULT FALSE,
+    has_trap BOOLEAN DEFAULT FALSE,
+    trap_damage INTEGER DEFAULT 0,
+    trap_tick_interval INTEGER DEFAULT 0,
+    status TEXT DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (zone_id) REFERENCES zones(id)
+);
+
+-- Exits
+CREATE TABLE IF NOT EXISTS exits (
+    id TEXT PRIMARY KEY,
+    from_room_id TEXT NOT NULL,
+    to_room_id TEXT NOT NULL,
+    keywords TEXT NOT NULL,
+    description TEXT,
+    is_hidden BOOLEAN DEFAULT FALSE,
+    is_obvious BOOLEAN DEFAULT TRUE,
+    allow_look_through BOOLEAN DEFAULT TRUE,
+    is_open BOOLEAN DEFAULT TRUE,
+    is_locked BOOLEAN DEFAULT FALSE,
+    requires_item_id TEXT,
+    FOREIGN KEY (from_room_id) REFERENCES rooms(id),
+    FOREIGN KEY (to_room_id) REFERENCES rooms(id),
+    FOREIGN KEY (requires_item_id) REFERENCES game_objects(id)
+);
+
+-- Game Objects
+CREATE TABLE IF NOT EXISTS game_objects (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    container_id TEXT,
+    container_type TEXT,
+    object_type TEXT NOT NULL,
+    is_obvious BOOLEAN DEFAULT TRUE,
+    is_hidden BOOLEAN DEFAULT FALSE,
+    can_pick_up BOOLEAN DEFAULT TRUE,
+    is_readable BOOLEAN DEFAULT FALSE,
+    read_text TEXT,
+    is_container BOOLEAN DEFAULT FALSE,
+    capacity REAL DEFAULT 0.0,
+    is_open BOOLEAN DEFAULT TRUE,
+    weight REAL DEFAULT 0.0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Entities
+CREATE TABLE IF NOT EXISTS entities (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    room_id TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    darkvision INTEGER DEFAULT 0,
+    is_hidden BOOLEAN DEFAULT FALSE,
+    health INTEGER DEFAULT 100,
+    max_health INTEGER DEFAULT 100,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (room_id) REFERENCES rooms(id)
+);
+
+-- Players
+CREATE TABLE IF NOT EXISTS players (
+    id TEXT PRIMARY KEY,
+    entity_id TEXT NOT NULL UNIQUE,
+    username TEXT UNIQUE NOT NULL,
+    password_hash TEXT,
+    mfa_secret TEXT,
+    last_login TIMESTAMP,
+    last_logout TIMESTAMP,
+    is_builder BOOLEAN DEFAULT FALSE,
+    is_admin BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (entity_id) REFERENCES entities(id)
+);
+
+-- NPCs
+CREATE TABLE IF NOT EXISTS npcs (
+    id TEXT PRIMARY KEY,
+    entity_id TEXT NOT NULL UNIQUE,
+    is_aggressive BOOLEAN DEFAULT FALSE,
+    is_merchant BOOLEAN DEFAULT FALSE,
+    greeting TEXT,
+    FOREIGN KEY (entity_id) REFERENCES entities(id)
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_objects_container ON game_objects(container_id);
+CREATE INDEX IF NOT EXISTS idx_objects_container_type ON game_objects(container_type);
+CREATE INDEX IF NOT EXISTS idx_exits_from_room ON exits(from_room_id);
+CREATE INDEX IF NOT EXISTS idx_exits_keywords ON exits(keywords);
+CREATE INDEX IF NOT EXISTS idx_rooms_zone ON rooms(zone_id);
+CREATE INDEX IF NOT EXISTS idx_entities_room ON entities(room_id);
+CREATE INDEX IF NOT EXISTS idx_players_username ON players(username);
+`
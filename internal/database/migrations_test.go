@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"mudengine/internal/config"
+)
+
+// TestMigratePreMigrationDatabase upgrades a database created before
+// migrations existed: the initial schema's tables, holding data, but no
+// schema_migrations table
+func TestMigratePreMigrationDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	if _, err := old.Exec(initialSchema); err != nil {
+		t.Fatalf("creating initial schema: %v", err)
+	}
+	_, err = old.Exec(`
+INSERT INTO game_objects (id, name, description, object_type)
+VALUES ('20000000-0000-0000-0000-000000000001', 'Rusty Iron Key', 'It has seen better days.', 'key')`)
+	if err != nil {
+		t.Fatalf("inserting object: %v", err)
+	}
+	old.Close()
+
+	cfg := &config.Config{
+		DBType:           "sqlite",
+		DBName:           path,
+		DBMaxConnections: 4,
+		DBMaxIdleConns:   4,
+	}
+	if err := Initialize(context.Background(), cfg); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	t.Cleanup(func() {
+		Close()
+		DB = nil
+	})
+
+	var version int
+	if err := DB.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		t.Fatalf("reading schema version: %v", err)
+	}
+	if want := migrations[len(migrations)-1].version; version != want {
+		t.Errorf("schema version = %d, want %d", version, want)
+	}
+
+	var keywordsJSON string
+	err = DB.QueryRow("SELECT keywords FROM game_objects WHERE id = '20000000-0000-0000-0000-000000000001'").Scan(&keywordsJSON)
+	if err != nil {
+		t.Fatalf("reading keywords: %v", err)
+	}
+	var keywords []string
+	if err := json.Unmarshal([]byte(keywordsJSON), &keywords); err != nil {
+		t.Fatalf("keywords %q are not JSON: %v", keywordsJSON, err)
+	}
+	if want := DeriveKeywords("Rusty Iron Key"); !slices.Equal(keywords, want) {
+		t.Errorf("backfilled keywords = %v, want %v", keywords, want)
+	}
+
+	// Reading rows scans the columns later migrations added
+	if _, err := GetRoom(StartingRoomID); err != nil {
+		t.Errorf("GetRoom: %v", err)
+	}
+	if _, err := GetExitsByRoom(LimboRoomID); err != nil {
+		t.Errorf("GetExitsByRoom: %v", err)
+	}
+	if socials, err := GetAllSocials(); err != nil || len(socials) == 0 {
+		t.Errorf("GetAllSocials = %d socials, %v; want the defaults", len(socials), err)
+	}
+
+	for _, index := range []string{"idx_objects_keywords", "idx_exits_to_room", "idx_players_username_lower"} {
+		var n int
+		if err := DB.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ?", index).Scan(&n); err != nil {
+			t.Fatalf("looking up %s: %v", index, err)
+		}
+		if n != 1 {
+			t.Errorf("index %s is missing", index)
+		}
+	}
+}
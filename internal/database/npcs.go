@@ -0,0 +1,206 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// NPC represents a non-player character. Its name, description, location
+// and health live on the paired entities row.
+type NPC struct {
+	ID           string  `json:"id"`
+	EntityID     string  `json:"entity_id"`
+	IsAggressive bool    `json:"is_aggressive"`
+	IsMerchant   bool    `json:"is_merchant"`
+	Greeting     string  `json:"greeting,omitempty"`
+	Entity       *Entity `json:"entity"`
+}
+
+// npcColumns selects an NPC joined with its entity (aliases n and e)
+const npcColumns = `
+			n.id, n.entity_id, n.is_aggressive, n.is_merchant, n.greeting,
+			e.id, e.name, e.description, e.room_id, e.entity_type,
+			e.darkvision, e.is_hidden, e.health, e.max_health,
+			e.created_at, e.updated_at`
+
+// scanNPC scans a single row selected with npcColumns
+func scanNPC(row rowScanner) (*NPC, error) {
+	npc := &NPC{Entity: &Entity{}}
+	var greeting sql.NullString
+
+	err := row.Scan(
+		&npc.ID, &npc.EntityID, &npc.IsAggressive, &npc.IsMerchant, &greeting,
+		&npc.Entity.ID, &npc.Entity.Name, &npc.Entity.Description, &npc.Entity.RoomID, &npc.Entity.EntityType,
+		&npc.Entity.Darkvision, &npc.Entity.IsHidden, &npc.Entity.Health, &npc.Entity.MaxHealth,
+		&npc.Entity.CreatedAt, &npc.Entity.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	npc.Greeting = greeting.String
+
+	return npc, nil
+}
+
+// CreateNPC creates an NPC together with its entity row in one transaction
+func CreateNPC(npc *NPC) error {
+	if npc.Entity == nil {
+		return fmt.Errorf("failed to create NPC: missing entity")
+	}
+
+	// Generate UUID if not provided
+	if npc.ID == "" {
+		npc.ID = uuid.New().String()
+	}
+	npc.Entity.EntityType = EntityTypeNPC
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertEntity(tx, npc.Entity); err != nil {
+		return err
+	}
+	npc.EntityID = npc.Entity.ID
+
+	_, err = tx.Exec(rebind(`
+		INSERT INTO npcs (id, entity_id, is_aggressive, is_merchant, greeting)
+		VALUES (?, ?, ?, ?, ?)
+	`), npc.ID, npc.EntityID, npc.IsAggressive, npc.IsMerchant, npc.Greeting)
+	if err != nil {
+		return fmt.Errorf("failed to create NPC: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit NPC: %w", err)
+	}
+
+	return nil
+}
+
+// GetNPC retrieves an NPC and its entity by NPC ID
+func GetNPC(id string) (*NPC, error) {
+	query := "SELECT" + npcColumns + `
+		FROM npcs n
+		JOIN entities e ON e.id = n.entity_id
+		WHERE n.id = ?
+	`
+
+	npc, err := scanNPC(DB.QueryRow(rebind(query), id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("NPC not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NPC: %w", err)
+	}
+
+	return npc, nil
+}
+
+// GetNPCsByRoom retrieves all NPCs currently in a room
+func GetNPCsByRoom(roomID string) ([]*NPC, error) {
+	query := "SELECT" + npcColumns + `
+		FROM npcs n
+		JOIN entities e ON e.id = n.entity_id
+		WHERE e.room_id = ?
+		ORDER BY e.name
+	`
+
+	rows, err := DB.Query(rebind(query), roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query NPCs: %w", err)
+	}
+	defer rows.Close()
+
+	var npcs []*NPC
+	for rows.Next() {
+		npc, err := scanNPC(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan NPC: %w", err)
+		}
+		npcs = append(npcs, npc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read NPCs: %w", err)
+	}
+
+	return npcs, nil
+}
+
+// UpdateNPC updates an NPC and its entity row in one transaction
+func UpdateNPC(npc *NPC) error {
+	if npc.Entity == nil {
+		return fmt.Errorf("failed to update NPC: missing entity")
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(rebind(`
+		UPDATE npcs SET is_aggressive = ?, is_merchant = ?, greeting = ?
+		WHERE id = ?
+	`), npc.IsAggressive, npc.IsMerchant, npc.Greeting, npc.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update NPC: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("NPC not found: %s", npc.ID)
+	}
+
+	if err := updateEntity(tx, npc.Entity); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit NPC: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteNPC deletes an NPC and its entity row in one transaction
+func DeleteNPC(id string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var entityID string
+	err = tx.QueryRow(rebind("SELECT entity_id FROM npcs WHERE id = ?"), id).Scan(&entityID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("NPC not found: %s", id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get NPC: %w", err)
+	}
+
+	// Delete the npc row first, it references the entity
+	if _, err := tx.Exec(rebind("DELETE FROM npcs WHERE id = ?"), id); err != nil {
+		return fmt.Errorf("failed to delete NPC: %w", err)
+	}
+
+	if _, err := tx.Exec(rebind("DELETE FROM entities WHERE id = ?"), entityID); err != nil {
+		return fmt.Errorf("failed to delete NPC entity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit NPC deletion: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import "testing"
+
+// createTestNPC creates an NPC in a room
+func createTestNPC(t *testing.T, name string, room *Room) *NPC {
+	t.Helper()
+
+	npc := &NPC{
+		IsMerchant: true,
+		Greeting:   "Welcome, traveller!",
+		Entity: &Entity{
+			Name:        name,
+			Description: "An NPC made for testing.",
+			RoomID:      room.ID,
+			Health:      50,
+			MaxHealth:   50,
+		},
+	}
+	if err := CreateNPC(npc); err != nil {
+		t.Fatalf("CreateNPC(%q): %v", name, err)
+	}
+
+	return npc
+}
+
+func TestCreateNPC(t *testing.T) {
+	openTestDB(t)
+
+	room := createTestRoom(t, "Market")
+	npc := createTestNPC(t, "a grumpy merchant", room)
+
+	got, err := GetNPC(npc.ID)
+	if err != nil {
+		t.Fatalf("GetNPC: %v", err)
+	}
+	if !got.IsMerchant || got.Greeting != "Welcome, traveller!" {
+		t.Errorf("merchant, greeting = %v, %q", got.IsMerchant, got.Greeting)
+	}
+	if got.EntityID != npc.Entity.ID || got.Entity.Name != "a grumpy merchant" || got.Entity.EntityType != EntityTypeNPC {
+		t.Errorf("entity = %s %q (%s), want %s %q (npc)",
+			got.EntityID, got.Entity.Name, got.Entity.EntityType, npc.Entity.ID, "a grumpy merchant")
+	}
+}
+
+func TestGetNPCsByRoom(t *testing.T) {
+	openTestDB(t)
+
+	market := createTestRoom(t, "Market")
+	dock := createTestRoom(t, "Dock")
+	createTestNPC(t, "a grumpy merchant", market)
+	createTestNPC(t, "an old fishwife", market)
+	createTestNPC(t, "a sailor", dock)
+
+	// Entities that aren't NPCs are left out
+	createTestEntity(t, "a stray cat", market)
+
+	npcs, err := GetNPCsByRoom(market.ID)
+	if err != nil {
+		t.Fatalf("GetNPCsByRoom: %v", err)
+	}
+	var names []string
+	for _, npc := range npcs {
+		names = append(names, npc.Entity.Name)
+	}
+	if len(names) != 2 || names[0] != "a grumpy merchant" || names[1] != "an old fishwife" {
+		t.Errorf("market NPCs = %v, want [a grumpy merchant an old fishwife]", names)
+	}
+}
+
+func TestDeleteNPC(t *testing.T) {
+	openTestDB(t)
+
+	room := createTestRoom(t, "Market")
+	npc := createTestNPC(t, "a grumpy merchant", room)
+
+	if err := DeleteNPC(npc.ID); err != nil {
+		t.Fatalf("DeleteNPC: %v", err)
+	}
+	if _, err := GetNPC(npc.ID); err == nil {
+		t.Error("GetNPC found the NPC after it was deleted")
+	}
+	if _, err := GetEntity(npc.EntityID); err == nil {
+		t.Error("the NPC's entity survived its deletion")
+	}
+	if err := DeleteNPC(npc.ID); err == nil {
+		t.Error("DeleteNPC of a missing NPC succeeded")
+	}
+}
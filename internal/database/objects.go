@@ -0,0 +1,560 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Container errors, returned when an object can't be put in or taken out
+var (
+	ErrNotContainer    = errors.New("not a container")
+	ErrContainerClosed = errors.New("container is closed")
+	ErrContainerFull   = errors.New("container is full")
+	ErrNotInContainer  = errors.New("object is not in that container")
+	ErrContainerLoop   = errors.New("container is inside the object")
+)
+
+// ErrInvalidContainerType is returned when an object would be placed in
+// something other than a room, player or object
+var ErrInvalidContainerType = errors.New("invalid container type")
+
+// Container types: what an object's container_id refers to
+const (
+	ContainerTypeRoom   = "room"
+	ContainerTypePlayer = "player"
+	ContainerTypeObject = "object"
+)
+
+// validateContainerType checks an object's container type. Empty is
+// allowed for objects that haven't been placed anywhere yet.
+func validateContainerType(containerType string) error {
+	switch containerType {
+	case "", ContainerTypeRoom, ContainerTypePlayer, ContainerTypeObject:
+		return nil
+	}
+
+	return fmt.Errorf("%w: %q (must be %s, %s or %s)", ErrInvalidContainerType,
+		containerType, ContainerTypeRoom, ContainerTypePlayer, ContainerTypeObject)
+}
+
+// GameObject represents an item in the world, a room, or another container
+type GameObject struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Keywords    []string `json:"keywords"`
+	Description string   `json:"description"`
+	ObjectType  string   `json:"object_type"`
+
+	// Location: the room, player or object holding this object
+	ContainerID   string `json:"container_id"`
+	ContainerType string `json:"container_type"`
+
+	// Visibility
+	IsObvious bool `json:"is_obvious"`
+	IsHidden  bool `json:"is_hidden"`
+
+	// Interaction
+	CanPickUp  bool    `json:"can_pick_up"`
+	IsReadable bool    `json:"is_readable"`
+	ReadText   string  `json:"read_text,omitempty"`
+	Weight     float64 `json:"weight"`
+
+	// Container properties
+	IsContainer bool    `json:"is_container"`
+	Capacity    float64 `json:"capacity"`
+	IsOpen      bool    `json:"is_open"`
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// objectKeywordStopWords are articles skipped when deriving keywords
+var objectKeywordStopWords = map[string]bool{
+	"a":    true,
+	"an":   true,
+	"the":  true,
+	"of":   true,
+	"some": true,
+}
+
+// DeriveKeywords builds a keyword list from an object's display name,
+// so "a rusty iron sword" can be referenced as rusty, iron or sword.
+// Used for objects created without explicit keywords.
+func DeriveKeywords(name string) []string {
+	keywords := []string{}
+	seen := make(map[string]bool)
+
+	for _, word := range strings.Fields(strings.ToLower(name)) {
+		word = strings.Trim(word, ".,;:!?'\"()")
+		if word == "" || objectKeywordStopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		keywords = append(keywords, word)
+	}
+
+	return keywords
+}
+
+// objectColumns lists the object columns in the order scanObject expects
+const objectColumns = `
+			id, name, keywords, description, object_type,
+			container_id, container_type,
+			is_obvious, is_hidden, can_pick_up, is_readable, read_text, weight,
+			is_container, capacity, is_open,
+			created_at, updated_at`
+
+// scanObject scans a single object row selected with objectColumns
+func scanObject(row rowScanner) (*GameObject, error) {
+	obj := &GameObject{}
+	var keywordsJSON string
+	var containerID, containerType, readText sql.NullString
+
+	err := row.Scan(
+		&obj.ID, &obj.Name, &keywordsJSON, &obj.Description, &obj.ObjectType,
+		&containerID, &containerType,
+		&obj.IsObvious, &obj.IsHidden, &obj.CanPickUp, &obj.IsReadable, &readText, &obj.Weight,
+		&obj.IsContainer, &obj.Capacity, &obj.IsOpen,
+		&obj.CreatedAt, &obj.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal keywords
+	if err := json.Unmarshal([]byte(keywordsJSON), &obj.Keywords); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal keywords: %w", err)
+	}
+
+	// Objects stored before keywords existed fall back to their name
+	if len(obj.Keywords) == 0 {
+		obj.Keywords = DeriveKeywords(obj.Name)
+	}
+
+	// Handle nullable columns
+	obj.ContainerID = containerID.String
+	obj.ContainerType = containerType.String
+	obj.ReadText = readText.String
+
+	return obj, nil
+}
+
+// queryObjects runs an object query and scans every resulting row
+func queryObjects(query string, args ...any) ([]*GameObject, error) {
+	rows, err := DB.Query(rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query objects: %w", err)
+	}
+	defer rows.Close()
+
+	var objects []*GameObject
+	for rows.Next() {
+		obj, err := scanObject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan object: %w", err)
+		}
+		objects = append(objects, obj)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read objects: %w", err)
+	}
+
+	return objects, nil
+}
+
+// CreateObject creates a new game object
+func CreateObject(obj *GameObject) error {
+	if err := validateContainerType(obj.ContainerType); err != nil {
+		return err
+	}
+
+	// Generate UUID if not provided
+	if obj.ID == "" {
+		obj.ID = uuid.New().String()
+	}
+
+	if len(obj.Keywords) == 0 {
+		obj.Keywords = DeriveKeywords(obj.Name)
+	}
+
+	// Set timestamps
+	now := time.Now()
+	obj.CreatedAt = now
+	obj.UpdatedAt = now
+
+	// Marshal keywords to JSON
+	keywordsJSON, err := json.Marshal(obj.Keywords)
+	if err != nil {
+		return fmt.Errorf("failed to marshal keywords: %w", err)
+	}
+
+	query := `
+		INSERT INTO game_objects (
+			id, name, keywords, description, object_type,
+			container_id, container_type,
+			is_obvious, is_hidden, can_pick_up, is_readable, read_text, weight,
+			is_container, capacity, is_open,
+			created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = DB.Exec(rebind(query),
+		obj.ID, obj.Name, string(keywordsJSON), obj.Description, obj.ObjectType,
+		obj.ContainerID, obj.ContainerType,
+		obj.IsObvious, obj.IsHidden, obj.CanPickUp, obj.IsReadable, obj.ReadText, obj.Weight,
+		obj.IsContainer, obj.Capacity, obj.IsOpen,
+		obj.CreatedAt, obj.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create object: %w", err)
+	}
+
+	return nil
+}
+
+// GetObject retrieves an object by ID
+func GetObject(id string) (*GameObject, error) {
+	query := "SELECT" + objectColumns + `
+		FROM game_objects
+		WHERE id = ?
+	`
+
+	obj, err := scanObject(DB.QueryRow(rebind(query), id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("object not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	return obj, nil
+}
+
+// GetObjectsByContainer retrieves all objects held by a container,
+// e.g. ("<room id>", "room") or ("<player id>", "player")
+func GetObjectsByContainer(containerID, containerType string) ([]*GameObject, error) {
+	query := "SELECT" + objectColumns + `
+		FROM game_objects
+		WHERE container_id = ? AND container_type = ?
+		ORDER BY name
+	`
+
+	return queryObjects(query, containerID, containerType)
+}
+
+// GetObjectsByRoom retrieves all objects lying in a room, ordered by name
+func GetObjectsByRoom(roomID string) ([]*GameObject, error) {
+	return GetObjectsByContainer(roomID, ContainerTypeRoom)
+}
+
+// ObjectTypeLight is the object type of light sources, which let their
+// carrier see in dark rooms
+const ObjectTypeLight = "light"
+
+// CarriesLight reports whether a player has a light source in their inventory
+func CarriesLight(entityID string) (bool, error) {
+	var found bool
+	err := DB.QueryRow(rebind(`
+		SELECT EXISTS(
+			SELECT 1 FROM game_objects
+			WHERE container_id = ? AND container_type = ? AND object_type = ?
+		)
+	`), entityID, ContainerTypePlayer, ObjectTypeLight).Scan(&found)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for light source: %w", err)
+	}
+
+	return found, nil
+}
+
+// UpdateObject updates an existing object
+func UpdateObject(obj *GameObject) error {
+	if err := validateContainerType(obj.ContainerType); err != nil {
+		return err
+	}
+
+	obj.UpdatedAt = time.Now()
+
+	// Marshal keywords to JSON
+	keywordsJSON, err := json.Marshal(obj.Keywords)
+	if err != nil {
+		return fmt.Errorf("failed to marshal keywords: %w", err)
+	}
+
+	query := `
+		UPDATE game_objects SET
+			name = ?, keywords = ?, description = ?, object_type = ?,
+			container_id = ?, container_type = ?,
+			is_obvious = ?, is_hidden = ?, can_pick_up = ?, is_readable = ?, read_text = ?, weight = ?,
+			is_container = ?, capacity = ?, is_open = ?,
+			updated_at = ?
+		WHERE id = ?
+	`
+
+	result, err := DB.Exec(rebind(query),
+		obj.Name, string(keywordsJSON), obj.Description, obj.ObjectType,
+		obj.ContainerID, obj.ContainerType,
+		obj.IsObvious, obj.IsHidden, obj.CanPickUp, obj.IsReadable, obj.ReadText, obj.Weight,
+		obj.IsContainer, obj.Capacity, obj.IsOpen,
+		obj.UpdatedAt, obj.ID,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update object: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("object not found: %s", obj.ID)
+	}
+
+	return nil
+}
+
+// MoveObject reparents an object to a new container
+func MoveObject(id, containerID, containerType string) error {
+	if err := validateContainerType(containerType); err != nil {
+		return err
+	}
+
+	result, err := DB.Exec(
+		rebind("UPDATE game_objects SET container_id = ?, container_type = ?, updated_at = ? WHERE id = ?"),
+		containerID, containerType, time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to move object: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("object not found: %s", id)
+	}
+
+	return nil
+}
+
+// DeleteObject deletes an object from the database
+func DeleteObject(id string) error {
+	result, err := DB.Exec(rebind("DELETE FROM game_objects WHERE id = ?"), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("object not found: %s", id)
+	}
+
+	return nil
+}
+
+// GetContainedWeight returns the total weight of the objects directly
+// inside a container object
+func GetContainedWeight(containerID string) (float64, error) {
+	return containedWeight(DB, containerID)
+}
+
+// containedWeight sums a container's contents using db, which may be a transaction
+func containedWeight(db queryRower, containerID string) (float64, error) {
+	var total float64
+	err := db.QueryRow(rebind(`
+		SELECT COALESCE(SUM(weight), 0) FROM game_objects
+		WHERE container_id = ? AND container_type = 'object'
+	`), containerID).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get contained weight: %w", err)
+	}
+
+	return total, nil
+}
+
+// SetContainerOpen opens or closes a container object
+func SetContainerOpen(id string, open bool) error {
+	result, err := DB.Exec(
+		rebind("UPDATE game_objects SET is_open = ?, updated_at = ? WHERE id = ? AND is_container = TRUE"),
+		open, time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update container: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		if _, err := GetObject(id); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s", ErrNotContainer, id)
+	}
+
+	return nil
+}
+
+// PutObjectInContainer moves an object into a container object. It fails
+// with ErrContainerClosed if the container is closed, ErrContainerFull if
+// the object's weight would exceed the remaining capacity, and
+// ErrContainerLoop if the container is the object or is somewhere inside
+// it, which would take both out of the world.
+func PutObjectInContainer(objectID, containerID string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkContainerOpen(tx, containerID); err != nil {
+		return err
+	}
+	if err := checkNotInside(tx, containerID, objectID); err != nil {
+		return err
+	}
+
+	var weight, capacity float64
+	err = tx.QueryRow(rebind("SELECT weight FROM game_objects WHERE id = ?"), objectID).Scan(&weight)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("object not found: %s", objectID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get object: %w", err)
+	}
+
+	if err := tx.QueryRow(rebind("SELECT capacity FROM game_objects WHERE id = ?"), containerID).Scan(&capacity); err != nil {
+		return fmt.Errorf("failed to get container: %w", err)
+	}
+
+	contained, err := containedWeight(tx, containerID)
+	if err != nil {
+		return err
+	}
+	if contained+weight > capacity {
+		return fmt.Errorf("%w: %s", ErrContainerFull, containerID)
+	}
+
+	_, err = tx.Exec(
+		rebind("UPDATE game_objects SET container_id = ?, container_type = 'object', updated_at = ? WHERE id = ?"),
+		containerID, time.Now(), objectID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to move object: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit object move: %w", err)
+	}
+
+	return nil
+}
+
+// TakeObjectFromContainer moves an object out of a container object to a
+// new holder, e.g. ("<player id>", "player"). The container must be open.
+func TakeObjectFromContainer(objectID, containerID, holderID, holderType string) error {
+	if err := validateContainerType(holderType); err != nil {
+		return err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkContainerOpen(tx, containerID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(rebind(`
+		UPDATE game_objects SET container_id = ?, container_type = ?, updated_at = ?
+		WHERE id = ? AND container_id = ? AND container_type = 'object'
+	`), holderID, holderType, time.Now(), objectID, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to move object: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrNotInContainer, objectID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit object move: %w", err)
+	}
+
+	return nil
+}
+
+// checkNotInside fails with ErrContainerLoop if containerID is objectID or
+// is held, at any depth, inside it. It follows container_id up through
+// the objects holding containerID until it reaches a room or player.
+func checkNotInside(db queryRower, containerID, objectID string) error {
+	seen := make(map[string]bool)
+	for id := containerID; !seen[id]; {
+		if id == objectID {
+			return fmt.Errorf("%w: %s", ErrContainerLoop, objectID)
+		}
+		seen[id] = true
+
+		var holderID, holderType sql.NullString
+		err := db.QueryRow(rebind("SELECT container_id, container_type FROM game_objects WHERE id = ?"), id).Scan(&holderID, &holderType)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get container: %w", err)
+		}
+
+		if holderType.String != ContainerTypeObject {
+			return nil
+		}
+		id = holderID.String
+	}
+
+	return nil
+}
+
+// checkContainerOpen verifies that id is an open container object
+func checkContainerOpen(db queryRower, id string) error {
+	var isContainer, isOpen bool
+	err := db.QueryRow(rebind("SELECT is_container, is_open FROM game_objects WHERE id = ?"), id).Scan(&isContainer, &isOpen)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("object not found: %s", id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get container: %w", err)
+	}
+
+	if !isContainer {
+		return fmt.Errorf("%w: %s", ErrNotContainer, id)
+	}
+	if !isOpen {
+		return fmt.Errorf("%w: %s", ErrContainerClosed, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package database
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// createTestObject creates an object lying in a room
+func createTestObject(t *testing.T, name string, room *Room) *GameObject {
+	t.Helper()
+
+	obj := &GameObject{
+		Name:          name,
+		Description:   "An object made for testing.",
+		ObjectType:    "misc",
+		ContainerID:   room.ID,
+		ContainerType: ContainerTypeRoom,
+		IsObvious:     true,
+		CanPickUp:     true,
+	}
+	if err := CreateObject(obj); err != nil {
+		t.Fatalf("CreateObject(%q): %v", name, err)
+	}
+
+	return obj
+}
+
+func TestObjectCRUD(t *testing.T) {
+	openTestDB(t)
+
+	room := createTestRoom(t, "Armory")
+	sword := createTestObject(t, "a rusty iron sword", room)
+
+	got, err := GetObject(sword.ID)
+	if err != nil {
+		t.Fatalf("GetObject: %v", err)
+	}
+	if got.Name != sword.Name || got.ContainerID != room.ID || got.ContainerType != ContainerTypeRoom {
+		t.Errorf("GetObject = %q in %s %s, want %q in room %s",
+			got.Name, got.ContainerType, got.ContainerID, sword.Name, room.ID)
+	}
+	if !slices.Equal(got.Keywords, []string{"rusty", "iron", "sword"}) {
+		t.Errorf("keywords = %v, want [rusty iron sword]", got.Keywords)
+	}
+
+	got.Description = "Pitted with rust, but still sharp."
+	got.Weight = 3.5
+	if err := UpdateObject(got); err != nil {
+		t.Fatalf("UpdateObject: %v", err)
+	}
+	got, err = GetObject(sword.ID)
+	if err != nil {
+		t.Fatalf("GetObject after update: %v", err)
+	}
+	if got.Description != "Pitted with rust, but still sharp." || got.Weight != 3.5 {
+		t.Errorf("after update got %q weighing %v", got.Description, got.Weight)
+	}
+
+	if err := DeleteObject(sword.ID); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+	if _, err := GetObject(sword.ID); err == nil {
+		t.Error("GetObject found the object after it was deleted")
+	}
+	if err := DeleteObject(sword.ID); err == nil {
+		t.Error("DeleteObject of a missing object succeeded")
+	}
+}
+
+func TestMoveObjectAndContainerQueries(t *testing.T) {
+	openTestDB(t)
+
+	room := createTestRoom(t, "Armory")
+	shield := createTestObject(t, "a round shield", room)
+	createTestObject(t, "a bent dagger", room)
+
+	const playerID = "test-player"
+	if err := MoveObject(shield.ID, playerID, ContainerTypePlayer); err != nil {
+		t.Fatalf("MoveObject: %v", err)
+	}
+
+	inRoom, err := GetObjectsByRoom(room.ID)
+	if err != nil {
+		t.Fatalf("GetObjectsByRoom: %v", err)
+	}
+	if len(inRoom) != 1 || inRoom[0].Name != "a bent dagger" {
+		t.Errorf("room holds %d object(s), want only the dagger", len(inRoom))
+	}
+
+	carried, err := GetObjectsByContainer(playerID, ContainerTypePlayer)
+	if err != nil {
+		t.Fatalf("GetObjectsByContainer: %v", err)
+	}
+	if len(carried) != 1 || carried[0].ID != shield.ID {
+		t.Errorf("player carries %d object(s), want only the shield", len(carried))
+	}
+
+	// The same ID as a different kind of container holds nothing
+	if objs, err := GetObjectsByContainer(playerID, ContainerTypeObject); err != nil || len(objs) != 0 {
+		t.Errorf("GetObjectsByContainer(object) = %d object(s), %v; want none", len(objs), err)
+	}
+
+	if err := MoveObject(shield.ID, room.ID, "pocket"); !errors.Is(err, ErrInvalidContainerType) {
+		t.Errorf("MoveObject to a bad container type = %v, want ErrInvalidContainerType", err)
+	}
+	if err := MoveObject("no-such-object", room.ID, ContainerTypeRoom); err == nil {
+		t.Error("MoveObject of a missing object succeeded")
+	}
+}
+
+// createTestContainer creates an open container lying in a room
+func createTestContainer(t *testing.T, name string, room *Room, capacity float64) *GameObject {
+	t.Helper()
+
+	obj := &GameObject{
+		Name:          name,
+		Description:   "A container made for testing.",
+		ObjectType:    "container",
+		ContainerID:   room.ID,
+		ContainerType: ContainerTypeRoom,
+		IsObvious:     true,
+		IsContainer:   true,
+		Capacity:      capacity,
+		IsOpen:        true,
+	}
+	if err := CreateObject(obj); err != nil {
+		t.Fatalf("CreateObject(%q): %v", name, err)
+	}
+
+	return obj
+}
+
+func TestPutAndTakeFromContainer(t *testing.T) {
+	openTestDB(t)
+
+	room := createTestRoom(t, "Storeroom")
+	chest := createTestContainer(t, "an oak chest", room, 10)
+	coin := createTestObject(t, "a gold coin", room)
+
+	if err := PutObjectInContainer(coin.ID, chest.ID); err != nil {
+		t.Fatalf("PutObjectInContainer: %v", err)
+	}
+	if objs, err := GetObjectsByContainer(chest.ID, ContainerTypeObject); err != nil || len(objs) != 1 {
+		t.Errorf("chest holds %d object(s), %v; want the coin", len(objs), err)
+	}
+
+	const playerID = "test-player"
+	if err := TakeObjectFromContainer(coin.ID, chest.ID, playerID, ContainerTypePlayer); err != nil {
+		t.Fatalf("TakeObjectFromContainer: %v", err)
+	}
+	got, err := GetObject(coin.ID)
+	if err != nil {
+		t.Fatalf("GetObject: %v", err)
+	}
+	if got.ContainerID != playerID || got.ContainerType != ContainerTypePlayer {
+		t.Errorf("coin is in %s %s, want player %s", got.ContainerType, got.ContainerID, playerID)
+	}
+
+	if err := TakeObjectFromContainer(coin.ID, chest.ID, playerID, ContainerTypePlayer); !errors.Is(err, ErrNotInContainer) {
+		t.Errorf("taking an object that isn't in the container = %v, want ErrNotInContainer", err)
+	}
+}
+
+func TestContainerClosed(t *testing.T) {
+	openTestDB(t)
+
+	room := createTestRoom(t, "Storeroom")
+	chest := createTestContainer(t, "an oak chest", room, 10)
+	coin := createTestObject(t, "a gold coin", room)
+
+	if err := PutObjectInContainer(coin.ID, chest.ID); err != nil {
+		t.Fatalf("PutObjectInContainer: %v", err)
+	}
+	if err := SetContainerOpen(chest.ID, false); err != nil {
+		t.Fatalf("SetContainerOpen(false): %v", err)
+	}
+
+	if err := TakeObjectFromContainer(coin.ID, chest.ID, room.ID, ContainerTypeRoom); !errors.Is(err, ErrContainerClosed) {
+		t.Errorf("taking from a closed container = %v, want ErrContainerClosed", err)
+	}
+	gem := createTestObject(t, "a red gem", room)
+	if err := PutObjectInContainer(gem.ID, chest.ID); !errors.Is(err, ErrContainerClosed) {
+		t.Errorf("putting in a closed container = %v, want ErrContainerClosed", err)
+	}
+
+	if err := SetContainerOpen(chest.ID, true); err != nil {
+		t.Fatalf("SetContainerOpen(true): %v", err)
+	}
+	if err := PutObjectInContainer(gem.ID, chest.ID); err != nil {
+		t.Errorf("putting in a reopened container: %v", err)
+	}
+}
+
+func TestContainerFull(t *testing.T) {
+	openTestDB(t)
+
+	room := createTestRoom(t, "Storeroom")
+	pouch := createTestContainer(t, "a leather pouch", room, 1)
+	coin := createTestObject(t, "a gold coin", room)
+	anvil := createTestObject(t, "an iron anvil", room)
+	anvil.Weight = 50
+	if err := UpdateObject(anvil); err != nil {
+		t.Fatalf("UpdateObject: %v", err)
+	}
+
+	if err := PutObjectInContainer(anvil.ID, pouch.ID); !errors.Is(err, ErrContainerFull) {
+		t.Errorf("putting in an object over capacity = %v, want ErrContainerFull", err)
+	}
+	if err := PutObjectInContainer(coin.ID, pouch.ID); err != nil {
+		t.Errorf("putting in an object within capacity: %v", err)
+	}
+}
+
+func TestNotAContainer(t *testing.T) {
+	openTestDB(t)
+
+	room := createTestRoom(t, "Storeroom")
+	coin := createTestObject(t, "a gold coin", room)
+	rock := createTestObject(t, "a grey rock", room)
+
+	if err := PutObjectInContainer(coin.ID, rock.ID); !errors.Is(err, ErrNotContainer) {
+		t.Errorf("PutObjectInContainer into a non-container = %v, want ErrNotContainer", err)
+	}
+	if err := SetContainerOpen(rock.ID, false); !errors.Is(err, ErrNotContainer) {
+		t.Errorf("SetContainerOpen on a non-container = %v, want ErrNotContainer", err)
+	}
+	if err := SetContainerOpen("no-such-object", false); err == nil || errors.Is(err, ErrNotContainer) {
+		t.Errorf("SetContainerOpen on a missing object = %v, want a not found error", err)
+	}
+}
+
+func TestContainerLoop(t *testing.T) {
+	openTestDB(t)
+
+	room := createTestRoom(t, "Storeroom")
+	crate := createTestContainer(t, "a wooden crate", room, 100)
+	box := createTestContainer(t, "a small box", room, 50)
+	tin := createTestContainer(t, "a tin", room, 10)
+
+	if err := PutObjectInContainer(box.ID, crate.ID); err != nil {
+		t.Fatalf("box into crate: %v", err)
+	}
+	if err := PutObjectInContainer(tin.ID, box.ID); err != nil {
+		t.Fatalf("tin into box: %v", err)
+	}
+
+	if err := PutObjectInContainer(crate.ID, crate.ID); !errors.Is(err, ErrContainerLoop) {
+		t.Errorf("crate into itself = %v, want ErrContainerLoop", err)
+	}
+	if err := PutObjectInContainer(crate.ID, box.ID); !errors.Is(err, ErrContainerLoop) {
+		t.Errorf("crate into the box it holds = %v, want ErrContainerLoop", err)
+	}
+	if err := PutObjectInContainer(crate.ID, tin.ID); !errors.Is(err, ErrContainerLoop) {
+		t.Errorf("crate into the tin two levels down = %v, want ErrContainerLoop", err)
+	}
+
+	got, err := GetObject(crate.ID)
+	if err != nil {
+		t.Fatalf("GetObject: %v", err)
+	}
+	if got.ContainerID != room.ID || got.ContainerType != ContainerTypeRoom {
+		t.Errorf("crate moved to %s %s after a rejected put", got.ContainerType, got.ContainerID)
+	}
+}
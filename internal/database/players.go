@@ -0,0 +1,221 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrPlayerNotFound is returned when no player matches a lookup
+var ErrPlayerNotFound = errors.New("player not found")
+
+// ErrUsernameTaken is returned when creating a player whose username exists
+var ErrUsernameTaken = errors.New("username taken")
+
+// Player represents a player account, linked to the entity that is
+// their character in the world
+type Player struct {
+	ID           string `json:"id"`
+	EntityID     string `json:"entity_id"`
+	Username     string `json:"username"`
+	PasswordHash string `json:"-"`
+	MFASecret    string `json:"-"` // Empty when MFA is not enrolled
+
+	// Session history
+	LastLogin  *time.Time `json:"last_login,omitempty"`
+	LastLogout *time.Time `json:"last_logout,omitempty"`
+
+	// Roles
+	IsBuilder bool `json:"is_builder"`
+	IsAdmin   bool `json:"is_admin"`
+
+	// Rules acceptance; RulesVersion is empty if they never accepted any
+	RulesVersion    string     `json:"rules_version,omitempty"`
+	RulesAcceptedAt *time.Time `json:"rules_accepted_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Permission keys a player can hold. Commands check these rather than the
+// role columns, so roles can later come from a table of their own.
+const (
+	KeyAdmin       = "admin"
+	KeyBuilder     = "builder"
+	KeyModerator   = "moderator"
+	KeyStoryteller = "storyteller"
+)
+
+// Keys returns the permission keys the player's roles grant. Admins hold
+// every key; builders hold the builder key.
+func (p *Player) Keys() map[string]bool {
+	keys := make(map[string]bool)
+	if p.IsAdmin {
+		keys[KeyAdmin] = true
+		keys[KeyBuilder] = true
+		keys[KeyModerator] = true
+		keys[KeyStoryteller] = true
+	}
+	if p.IsBuilder {
+		keys[KeyBuilder] = true
+	}
+
+	return keys
+}
+
+// GetPlayerKeys retrieves a player's roles by player ID and returns the
+// permission keys they grant
+func GetPlayerKeys(playerID string) (map[string]bool, error) {
+	player := &Player{ID: playerID}
+
+	err := DB.QueryRow(rebind("SELECT is_builder, is_admin FROM players WHERE id = ?"), playerID).Scan(
+		&player.IsBuilder, &player.IsAdmin,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrPlayerNotFound, playerID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get player keys: %w", err)
+	}
+
+	return player.Keys(), nil
+}
+
+// GetPlayerByUsername retrieves a player account by username, ignoring case
+func GetPlayerByUsername(username string) (*Player, error) {
+	player := &Player{}
+	var passwordHash, mfaSecret sql.NullString
+	var lastLogin, lastLogout, rulesAcceptedAt sql.NullTime
+
+	query := `
+		SELECT
+			id, entity_id, username, password_hash, mfa_secret,
+			last_login, last_logout, is_builder, is_admin,
+			rules_version, rules_accepted_at, created_at
+		FROM players
+		WHERE lower(username) = lower(?)
+	`
+
+	err := DB.QueryRow(rebind(query), username).Scan(
+		&player.ID, &player.EntityID, &player.Username, &passwordHash, &mfaSecret,
+		&lastLogin, &lastLogout, &player.IsBuilder, &player.IsAdmin,
+		&player.RulesVersion, &rulesAcceptedAt, &player.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrPlayerNotFound, username)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get player: %w", err)
+	}
+
+	// Handle nullable columns
+	player.PasswordHash = passwordHash.String
+	player.MFASecret = mfaSecret.String
+	if lastLogin.Valid {
+		player.LastLogin = &lastLogin.Time
+	}
+	if lastLogout.Valid {
+		player.LastLogout = &lastLogout.Time
+	}
+	if rulesAcceptedAt.Valid {
+		player.RulesAcceptedAt = &rulesAcceptedAt.Time
+	}
+
+	return player, nil
+}
+
+// CreatePlayer creates a player account and its entity in one transaction.
+// The password is stored as a bcrypt hash and the player starts in
+// StartingRoomID. Usernames are unique regardless of case, so "Bob" is
+// taken once "bob" exists, but are stored as typed.
+func CreatePlayer(username, password string) (*Player, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	entity := &Entity{
+		Name:        username,
+		Description: "A new adventurer, still finding their way.",
+		RoomID:      StartingRoomID,
+		EntityType:  EntityTypePlayer,
+		Health:      100,
+		MaxHealth:   100,
+	}
+	if err := insertEntity(tx, entity); err != nil {
+		return nil, err
+	}
+
+	player := &Player{
+		ID:           uuid.New().String(),
+		EntityID:     entity.ID,
+		Username:     username,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now(),
+	}
+
+	_, err = tx.Exec(rebind(`
+		INSERT INTO players (id, entity_id, username, password_hash, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`), player.ID, player.EntityID, player.Username, player.PasswordHash, player.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("%w: %s", ErrUsernameTaken, username)
+		}
+		return nil, fmt.Errorf("failed to create player: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit player: %w", err)
+	}
+
+	return player, nil
+}
+
+// AcceptRules records that a player accepted the given version of the
+// server rules, replacing any earlier acceptance
+func AcceptRules(playerID, version string) error {
+	result, err := DB.Exec(
+		rebind("UPDATE players SET rules_version = ?, rules_accepted_at = ? WHERE id = ?"),
+		version, time.Now(), playerID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record rules acceptance: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrPlayerNotFound, playerID)
+	}
+
+	return nil
+}
+
+// isUniqueViolation reports whether err is a UNIQUE constraint failure
+// from either driver
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+
+	// 23505 is PostgreSQL's unique_violation
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func TestUsernamesIgnoreCase(t *testing.T) {
+	openTestDB(t)
+
+	bob, err := CreatePlayer("Bob", "hunter2")
+	if err != nil {
+		t.Fatalf("CreatePlayer: %v", err)
+	}
+
+	if _, err := CreatePlayer("bob", "hunter3"); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("CreatePlayer with the same name in another case = %v, want ErrUsernameTaken", err)
+	}
+
+	got, err := GetPlayerByUsername("BOB")
+	if err != nil {
+		t.Fatalf("GetPlayerByUsername: %v", err)
+	}
+	if got.ID != bob.ID || got.Username != "Bob" {
+		t.Errorf("GetPlayerByUsername(BOB) = %s %q, want %s %q", got.ID, got.Username, bob.ID, "Bob")
+	}
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"postgres unique violation", &pq.Error{Code: "23505"}, true},
+		{"wrapped postgres unique violation", fmt.Errorf("insert: %w", &pq.Error{Code: "23505"}), true},
+		{"postgres foreign key violation", &pq.Error{Code: "23503"}, false},
+		{"other error", errors.New("boom"), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUniqueViolation(tt.err); got != tt.want {
+				t.Errorf("isUniqueViolation(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestUsernamesIgnoreCasePostgres runs against the PostgreSQL database in
+// MUD_TEST_POSTGRES_DSN, and is skipped when it isn't set
+func TestUsernamesIgnoreCasePostgres(t *testing.T) {
+	dsn := os.Getenv("MUD_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("MUD_TEST_POSTGRES_DSN not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("opening PostgreSQL: %v", err)
+	}
+	prevDB, prevDriver := DB, driver
+	DB, driver = db, "postgres"
+	t.Cleanup(func() {
+		db.Close()
+		DB, driver = prevDB, prevDriver
+	})
+
+	if err := initializeSchema(context.Background()); err != nil {
+		t.Fatalf("initializeSchema: %v", err)
+	}
+
+	username := "Test" + uuid.New().String()[:8]
+	player, err := CreatePlayer(username, "hunter2")
+	if err != nil {
+		t.Fatalf("CreatePlayer: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM players WHERE id = $1`, player.ID)
+		db.Exec(`DELETE FROM entities WHERE id = $1`, player.EntityID)
+	})
+
+	if _, err := CreatePlayer(strings.ToLower(username), "hunter3"); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("CreatePlayer with the same name in another case = %v, want ErrUsernameTaken", err)
+	}
+}
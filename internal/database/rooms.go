@@ -3,12 +3,69 @@ package database
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
 
+// ErrExitNotFound is returned when an exit lookup or update matches no row
+var ErrExitNotFound = errors.New("exit not found")
+
+// ErrRoomHasIncomingExits is returned when deleting a room other rooms lead to
+var ErrRoomHasIncomingExits = errors.New("room has incoming exits")
+
+// ErrInvalidRoom is returned when a room's title or description can't be saved
+var ErrInvalidRoom = errors.New("invalid room")
+
+// Room text limits, in characters, set from ROOM_TITLE_MAX_LEN and
+// ROOM_DESC_MAX_LEN by SetRoomLimits. Zero means no limit.
+var (
+	roomTitleMaxLen atomic.Int64
+	roomDescMaxLen  atomic.Int64
+)
+
+// SetRoomLimits sets the longest title and description CreateRoom and
+// UpdateRoom accept. Safe to call while rooms are being saved.
+func SetRoomLimits(titleMax, descMax int) {
+	roomTitleMaxLen.Store(int64(titleMax))
+	roomDescMaxLen.Store(int64(descMax))
+}
+
+// validateRoomText checks a room's title and description before saving.
+// Titles are a single line, so they may not contain any control
+// characters. Descriptions may use newlines and tabs but no others, since
+// escape sequences and the like would corrupt players' screens.
+func validateRoomText(room *Room) error {
+	if strings.TrimSpace(room.Title) == "" {
+		return fmt.Errorf("%w: title cannot be empty", ErrInvalidRoom)
+	}
+	if limit, n := roomTitleMaxLen.Load(), int64(utf8.RuneCountInString(room.Title)); limit > 0 && n > limit {
+		return fmt.Errorf("%w: title is %d characters, the limit is %d", ErrInvalidRoom, n, limit)
+	}
+	if strings.IndexFunc(room.Title, unicode.IsControl) >= 0 {
+		return fmt.Errorf("%w: title cannot contain control characters or line breaks", ErrInvalidRoom)
+	}
+
+	if limit, n := roomDescMaxLen.Load(), int64(utf8.RuneCountInString(room.Description)); limit > 0 && n > limit {
+		return fmt.Errorf("%w: description is %d characters, the limit is %d", ErrInvalidRoom, n, limit)
+	}
+	if strings.IndexFunc(room.Description, func(r rune) bool {
+		return unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t'
+	}) >= 0 {
+		return fmt.Errorf("%w: description cannot contain control characters", ErrInvalidRoom)
+	}
+
+	return nil
+}
+
 // Room represents a room in the game world
 type Room struct {
 	ID          string `json:"id"`
@@ -32,6 +89,10 @@ type Room struct {
 	// Status effects
 	Status string `json:"status"`
 
+	// Ambiance is a sound identifier (e.g. "rain.wav") for clients with media
+	// support. Empty means the zone's ambiance applies.
+	Ambiance string `json:"ambiance,omitempty"`
+
 	// Metadata
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -55,6 +116,7 @@ type Exit struct {
 	IsOpen           bool     `json:"is_open"`
 	IsLocked         bool     `json:"is_locked"`
 	RequiresItemID   *string  `json:"requires_item_id,omitempty"`
+	ConsumesKey      bool     `json:"consumes_key"` // Required item is used up on passage
 }
 
 // Zone represents a grouping of rooms
@@ -65,10 +127,21 @@ type Zone struct {
 	Theme       string    `json:"theme"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// RespawnRoomID is where players who die in this zone recover.
+	// Nil means the global respawn room is used.
+	RespawnRoomID *string `json:"respawn_room_id,omitempty"`
+
+	// Ambiance is the default sound identifier for the zone's rooms
+	Ambiance string `json:"ambiance,omitempty"`
 }
 
 // CreateRoom creates a new room in the database
 func CreateRoom(room *Room) error {
+	if err := validateRoomText(room); err != nil {
+		return err
+	}
+
 	// Generate UUID if not provided
 	if room.ID == "" {
 		room.ID = uuid.New().String()
@@ -83,15 +156,15 @@ func CreateRoom(room *Room) error {
 		INSERT INTO rooms (
 			id, zone_id, title, description, terrain, darkness,
 			blocks_magic, restricts_movement, no_teleport_in, no_teleport_out,
-			has_trap, trap_damage, trap_tick_interval, status,
+			has_trap, trap_damage, trap_tick_interval, status, ambiance,
 			created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := DB.Exec(query,
+	_, err := DB.Exec(rebind(query),
 		room.ID, room.ZoneID, room.Title, room.Description, room.Terrain, room.Darkness,
 		room.BlocksMagic, room.RestrictsMovement, room.NoTeleportIn, room.NoTeleportOut,
-		room.HasTrap, room.TrapDamage, room.TrapTickInterval, room.Status,
+		room.HasTrap, room.TrapDamage, room.TrapTickInterval, room.Status, room.Ambiance,
 		room.CreatedAt, room.UpdatedAt,
 	)
 
@@ -110,16 +183,16 @@ func GetRoom(id string) (*Room, error) {
 		SELECT 
 			id, zone_id, title, description, terrain, darkness,
 			blocks_magic, restricts_movement, no_teleport_in, no_teleport_out,
-			has_trap, trap_damage, trap_tick_interval, status,
+			has_trap, trap_damage, trap_tick_interval, status, ambiance,
 			created_at, updated_at
 		FROM rooms
 		WHERE id = ?
 	`
 
-	err := DB.QueryRow(query, id).Scan(
+	err := DB.QueryRow(rebind(query), id).Scan(
 		&room.ID, &room.ZoneID, &room.Title, &room.Description, &room.Terrain, &room.Darkness,
 		&room.BlocksMagic, &room.RestrictsMovement, &room.NoTeleportIn, &room.NoTeleportOut,
-		&room.HasTrap, &room.TrapDamage, &room.TrapTickInterval, &room.Status,
+		&room.HasTrap, &room.TrapDamage, &room.TrapTickInterval, &room.Status, &room.Ambiance,
 		&room.CreatedAt, &room.UpdatedAt,
 	)
 
@@ -140,20 +213,32 @@ func GetRoom(id string) (*Room, error) {
 	return room, nil
 }
 
+// GetRoomOrLimbo retrieves a room by ID, falling back to the Limbo room
+// when it can't be loaded (for example after a builder deleted it)
+func GetRoomOrLimbo(id string) (*Room, error) {
+	room, err := GetRoom(id)
+	if err == nil {
+		return room, nil
+	}
+
+	log.Printf("Warning: could not load room %s, using limbo: %v", id, err)
+	return GetRoom(LimboRoomID)
+}
+
 // GetRoomsByZone retrieves all rooms in a zone
 func GetRoomsByZone(zoneID string) ([]*Room, error) {
 	query := `
 		SELECT 
 			id, zone_id, title, description, terrain, darkness,
 			blocks_magic, restricts_movement, no_teleport_in, no_teleport_out,
-			has_trap, trap_damage, trap_tick_interval, status,
+			has_trap, trap_damage, trap_tick_interval, status, ambiance,
 			created_at, updated_at
 		FROM rooms
 		WHERE zone_id = ?
 		ORDER BY title
 	`
 
-	rows, err := DB.Query(query, zoneID)
+	rows, err := DB.Query(rebind(query), zoneID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query rooms: %w", err)
 	}
@@ -165,7 +250,7 @@ func GetRoomsByZone(zoneID string) ([]*Room, error) {
 		err := rows.Scan(
 			&room.ID, &room.ZoneID, &room.Title, &room.Description, &room.Terrain, &room.Darkness,
 			&room.BlocksMagic, &room.RestrictsMovement, &room.NoTeleportIn, &room.NoTeleportOut,
-			&room.HasTrap, &room.TrapDamage, &room.TrapTickInterval, &room.Status,
+			&room.HasTrap, &room.TrapDamage, &room.TrapTickInterval, &room.Status, &room.Ambiance,
 			&room.CreatedAt, &room.UpdatedAt,
 		)
 		if err != nil {
@@ -179,21 +264,25 @@ func GetRoomsByZone(zoneID string) ([]*Room, error) {
 
 // UpdateRoom updates an existing room
 func UpdateRoom(room *Room) error {
+	if err := validateRoomText(room); err != nil {
+		return err
+	}
+
 	room.UpdatedAt = time.Now()
 
 	query := `
 		UPDATE rooms SET
 			zone_id = ?, title = ?, description = ?, terrain = ?, darkness = ?,
 			blocks_magic = ?, restricts_movement = ?, no_teleport_in = ?, no_teleport_out = ?,
-			has_trap = ?, trap_damage = ?, trap_tick_interval = ?, status = ?,
+			has_trap = ?, trap_damage = ?, trap_tick_interval = ?, status = ?, ambiance = ?,
 			updated_at = ?
 		WHERE id = ?
 	`
 
-	result, err := DB.Exec(query,
+	result, err := DB.Exec(rebind(query),
 		room.ZoneID, room.Title, room.Description, room.Terrain, room.Darkness,
 		room.BlocksMagic, room.RestrictsMovement, room.NoTeleportIn, room.NoTeleportOut,
-		room.HasTrap, room.TrapDamage, room.TrapTickInterval, room.Status,
+		room.HasTrap, room.TrapDamage, room.TrapTickInterval, room.Status, room.Ambiance,
 		room.UpdatedAt, room.ID,
 	)
 
@@ -213,30 +302,61 @@ func UpdateRoom(room *Room) error {
 	return nil
 }
 
-// DeleteRoom deletes a room from the database
-func DeleteRoom(id string) error {
+// DeleteRoom deletes a room from the database along with every exit from
+// or to it. Unless force is set, a room that other rooms lead into is left
+// alone: the error wraps ErrRoomHasIncomingExits and those exits are
+// returned, so the builder can reroute them first.
+//
+// The check and the deletes share a transaction, so a failure part way,
+// such as an entity still standing in the room, leaves every exit in place.
+func DeleteRoom(id string, force bool) ([]*Exit, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if !force {
+		incoming, err := exitsToRoom(tx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		// Exits looping back into the room itself go with it
+		incoming = slices.DeleteFunc(incoming, func(exit *Exit) bool {
+			return exit.FromRoomID == id
+		})
+		if len(incoming) > 0 {
+			return incoming, fmt.Errorf("%w: %d exit(s) lead to %s", ErrRoomHasIncomingExits, len(incoming), id)
+		}
+	}
+
 	// First delete all exits from/to this room
-	_, err := DB.Exec("DELETE FROM exits WHERE from_room_id = ? OR to_room_id = ?", id, id)
+	_, err = tx.Exec(rebind("DELETE FROM exits WHERE from_room_id = ? OR to_room_id = ?"), id, id)
 	if err != nil {
-		return fmt.Errorf("failed to delete room exits: %w", err)
+		return nil, fmt.Errorf("failed to delete room exits: %w", err)
 	}
 
 	// Delete the room
-	result, err := DB.Exec("DELETE FROM rooms WHERE id = ?", id)
+	result, err := tx.Exec(rebind("DELETE FROM rooms WHERE id = ?"), id)
 	if err != nil {
-		return fmt.Errorf("failed to delete room: %w", err)
+		return nil, fmt.Errorf("failed to delete room: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("room not found: %s", id)
+		return nil, fmt.Errorf("room not found: %s", id)
 	}
 
-	return nil
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit room deletion: %w", err)
+	}
+
+	return nil, nil
 }
 
 // GetAllRooms retrieves all rooms (use with caution for large databases)
@@ -245,13 +365,13 @@ func GetAllRooms() ([]*Room, error) {
 		SELECT 
 			id, zone_id, title, description, terrain, darkness,
 			blocks_magic, restricts_movement, no_teleport_in, no_teleport_out,
-			has_trap, trap_damage, trap_tick_interval, status,
+			has_trap, trap_damage, trap_tick_interval, status, ambiance,
 			created_at, updated_at
 		FROM rooms
 		ORDER BY title
 	`
 
-	rows, err := DB.Query(query)
+	rows, err := DB.Query(rebind(query))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query rooms: %w", err)
 	}
@@ -263,7 +383,7 @@ func GetAllRooms() ([]*Room, error) {
 		err := rows.Scan(
 			&room.ID, &room.ZoneID, &room.Title, &room.Description, &room.Terrain, &room.Darkness,
 			&room.BlocksMagic, &room.RestrictsMovement, &room.NoTeleportIn, &room.NoTeleportOut,
-			&room.HasTrap, &room.TrapDamage, &room.TrapTickInterval, &room.Status,
+			&room.HasTrap, &room.TrapDamage, &room.TrapTickInterval, &room.Status, &room.Ambiance,
 			&room.CreatedAt, &room.UpdatedAt,
 		)
 		if err != nil {
@@ -277,6 +397,11 @@ func GetAllRooms() ([]*Room, error) {
 
 // CreateExit creates a new exit between rooms
 func CreateExit(exit *Exit) error {
+	return insertExit(DB, exit)
+}
+
+// insertExit inserts an exit using db, which may be a transaction
+func insertExit(db execer, exit *Exit) error {
 	// Generate UUID if not provided
 	if exit.ID == "" {
 		exit.ID = uuid.New().String()
@@ -292,14 +417,14 @@ func CreateExit(exit *Exit) error {
 		INSERT INTO exits (
 			id, from_room_id, to_room_id, keywords, description,
 			is_hidden, is_obvious, allow_look_through, is_open, is_locked,
-			requires_item_id
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			requires_item_id, consumes_key
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err = DB.Exec(query,
+	_, err = db.Exec(rebind(query),
 		exit.ID, exit.FromRoomID, exit.ToRoomID, string(keywordsJSON), exit.Description,
 		exit.IsHidden, exit.IsObvious, exit.AllowLookThrough, exit.IsOpen, exit.IsLocked,
-		exit.RequiresItemID,
+		exit.RequiresItemID, exit.ConsumesKey,
 	)
 
 	if err != nil {
@@ -309,18 +434,155 @@ func CreateExit(exit *Exit) error {
 	return nil
 }
 
-// GetExitsByRoom retrieves all exits from a room
-func GetExitsByRoom(roomID string) ([]*Exit, error) {
-	query := `
-		SELECT 
+// oppositeDirections maps each compass and vertical direction to its reverse
+var oppositeDirections = map[string]string{
+	"north": "south", "south": "north",
+	"east": "west", "west": "east",
+	"northeast": "southwest", "southwest": "northeast",
+	"northwest": "southeast", "southeast": "northwest",
+	"up": "down", "down": "up",
+	"n": "s", "s": "n",
+	"e": "w", "w": "e",
+	"ne": "sw", "sw": "ne",
+	"nw": "se", "se": "nw",
+	"u": "d", "d": "u",
+}
+
+// OppositeDirection returns the reverse of a direction keyword, such as
+// "south" for "north" or "sw" for "ne". It reports false for keywords
+// that aren't directions.
+func OppositeDirection(direction string) (string, bool) {
+	opposite, ok := oppositeDirections[strings.ToLower(direction)]
+	return opposite, ok
+}
+
+// CreateTwoWayExit creates exit and a matching exit back from its
+// destination, named for the opposite of exit's first keyword. Both are
+// created in one transaction, so neither exists if either fails.
+//
+// If the destination already has an exit in the opposite direction only
+// exit is created and the returned reverse is nil, so callers can warn.
+func CreateTwoWayExit(exit *Exit) (reverse *Exit, err error) {
+	if len(exit.Keywords) == 0 {
+		return nil, fmt.Errorf("failed to create two-way exit: missing keywords")
+	}
+	back, ok := OppositeDirection(exit.Keywords[0])
+	if !ok {
+		return nil, fmt.Errorf("failed to create two-way exit: %q has no opposite direction", exit.Keywords[0])
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertExit(tx, exit); err != nil {
+		return nil, err
+	}
+
+	taken, err := exitKeywordTaken(tx, exit.ToRoomID, back)
+	if err != nil {
+		return nil, err
+	}
+	if !taken {
+		reverse = &Exit{
+			FromRoomID: exit.ToRoomID,
+			ToRoomID:   exit.FromRoomID,
+			Keywords:   []string{back},
+			IsObvious:  exit.IsObvious,
+			IsOpen:     exit.IsOpen,
+		}
+		if err := insertExit(tx, reverse); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit exits: %w", err)
+	}
+
+	return reverse, nil
+}
+
+// exitKeywordTaken reports whether any exit leaving roomID answers to keyword
+func exitKeywordTaken(tx *sql.Tx, roomID, keyword string) (bool, error) {
+	rows, err := tx.Query(rebind("SELECT keywords FROM exits WHERE from_room_id = ?"), roomID)
+	if err != nil {
+		return false, fmt.Errorf("failed to query exits: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var keywordsJSON string
+		if err := rows.Scan(&keywordsJSON); err != nil {
+			return false, fmt.Errorf("failed to scan exit: %w", err)
+		}
+
+		// Malformed keywords match nothing, as when the exit is loaded
+		var keywords []string
+		if err := json.Unmarshal([]byte(keywordsJSON), &keywords); err != nil {
+			continue
+		}
+		for _, k := range keywords {
+			if strings.EqualFold(k, keyword) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, rows.Err()
+}
+
+// exitColumns lists the exit columns in the order scanExit expects
+const exitColumns = `
 			id, from_room_id, to_room_id, keywords, description,
 			is_hidden, is_obvious, allow_look_through, is_open, is_locked,
-			requires_item_id
-		FROM exits
-		WHERE from_room_id = ?
-	`
+			requires_item_id, consumes_key`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanExit scans a single exit row selected with exitColumns
+func scanExit(row rowScanner) (*Exit, error) {
+	exit := &Exit{}
+	var keywordsJSON string
+	var requiresItemID sql.NullString
 
-	rows, err := DB.Query(query, roomID)
+	err := row.Scan(
+		&exit.ID, &exit.FromRoomID, &exit.ToRoomID, &keywordsJSON, &exit.Description,
+		&exit.IsHidden, &exit.IsObvious, &exit.AllowLookThrough, &exit.IsOpen, &exit.IsLocked,
+		&requiresItemID, &exit.ConsumesKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bad keywords (a hand-edited row or a bad import) cost only this exit
+	// its keywords rather than failing every exit in the room
+	if err := json.Unmarshal([]byte(keywordsJSON), &exit.Keywords); err != nil {
+		log.Printf("Warning: exit %s has malformed keywords %q, loading it without any: %v", exit.ID, keywordsJSON, err)
+		exit.Keywords = []string{}
+	}
+
+	// Handle nullable requires_item_id
+	if requiresItemID.Valid {
+		exit.RequiresItemID = &requiresItemID.String
+	}
+
+	return exit, nil
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// queryExits runs an exit query and scans every resulting row
+func queryExits(db querier, query string, args ...any) ([]*Exit, error) {
+	rows, err := db.Query(rebind(query), args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query exits: %w", err)
 	}
@@ -328,38 +590,162 @@ func GetExitsByRoom(roomID string) ([]*Exit, error) {
 
 	var exits []*Exit
 	for rows.Next() {
-		exit := &Exit{}
-		var keywordsJSON string
-		var requiresItemID sql.NullString
-
-		err := rows.Scan(
-			&exit.ID, &exit.FromRoomID, &exit.ToRoomID, &keywordsJSON, &exit.Description,
-			&exit.IsHidden, &exit.IsObvious, &exit.AllowLookThrough, &exit.IsOpen, &exit.IsLocked,
-			&requiresItemID,
-		)
+		exit, err := scanExit(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan exit: %w", err)
 		}
+		exits = append(exits, exit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read exits: %w", err)
+	}
 
-		// Unmarshal keywords
-		if err := json.Unmarshal([]byte(keywordsJSON), &exit.Keywords); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal keywords: %w", err)
-		}
+	return exits, nil
+}
 
-		// Handle nullable requires_item_id
-		if requiresItemID.Valid {
-			exit.RequiresItemID = &requiresItemID.String
-		}
+// GetExitByID retrieves a single exit by ID
+func GetExitByID(id string) (*Exit, error) {
+	query := "SELECT" + exitColumns + `
+		FROM exits
+		WHERE id = ?
+	`
 
-		exits = append(exits, exit)
+	exit, err := scanExit(DB.QueryRow(rebind(query), id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrExitNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exit: %w", err)
+	}
+
+	return exit, nil
+}
+
+// GetExitsByRoom retrieves all exits from a room
+func GetExitsByRoom(roomID string) ([]*Exit, error) {
+	query := "SELECT" + exitColumns + `
+		FROM exits
+		WHERE from_room_id = ?
+	`
+
+	return queryExits(DB, query, roomID)
+}
+
+// GetExitsToRoom retrieves all exits leading into a room
+func GetExitsToRoom(roomID string) ([]*Exit, error) {
+	return exitsToRoom(DB, roomID)
+}
+
+// exitsToRoom is GetExitsToRoom on either the database or a transaction
+func exitsToRoom(db querier, roomID string) ([]*Exit, error) {
+	query := "SELECT" + exitColumns + `
+		FROM exits
+		WHERE to_room_id = ?
+		ORDER BY from_room_id
+	`
+
+	return queryExits(db, query, roomID)
+}
+
+// GetAllExits retrieves all exits (use with caution for large databases)
+func GetAllExits() ([]*Exit, error) {
+	query := "SELECT" + exitColumns + `
+		FROM exits
+		ORDER BY from_room_id
+	`
+
+	return queryExits(DB, query)
+}
+
+// FindDanglingExits retrieves exits whose destination room no longer
+// exists, ordered by source room so they can be reported grouped
+func FindDanglingExits() ([]*Exit, error) {
+	return danglingExits(DB)
+}
+
+// danglingExits is FindDanglingExits on either the database or a transaction
+func danglingExits(db querier) ([]*Exit, error) {
+	query := "SELECT" + exitColumns + `
+		FROM exits
+		WHERE to_room_id NOT IN (SELECT id FROM rooms)
+		ORDER BY from_room_id
+	`
+
+	return queryExits(db, query)
+}
+
+// DeleteDanglingExits deletes every exit whose destination room no longer
+// exists, in one transaction, and returns the exits it removed. The delete
+// repeats the dangling condition rather than going by ID, so an exit
+// re-pointed at a real room in the meantime is kept.
+func DeleteDanglingExits() ([]*Exit, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	exits, err := danglingExits(tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(exits) == 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.Exec("DELETE FROM exits WHERE to_room_id NOT IN (SELECT id FROM rooms)"); err != nil {
+		return nil, fmt.Errorf("failed to delete dangling exits: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit exit deletions: %w", err)
 	}
 
 	return exits, nil
 }
 
+// UpdateExit updates an existing exit
+func UpdateExit(exit *Exit) error {
+	// Marshal keywords to JSON
+	keywordsJSON, err := json.Marshal(exit.Keywords)
+	if err != nil {
+		return fmt.Errorf("failed to marshal keywords: %w", err)
+	}
+
+	query := `
+		UPDATE exits SET
+			from_room_id = ?, to_room_id = ?, keywords = ?, description = ?,
+			is_hidden = ?, is_obvious = ?, allow_look_through = ?, is_open = ?, is_locked = ?,
+			requires_item_id = ?, consumes_key = ?
+		WHERE id = ?
+	`
+
+	result, err := DB.Exec(rebind(query),
+		exit.FromRoomID, exit.ToRoomID, string(keywordsJSON), exit.Description,
+		exit.IsHidden, exit.IsObvious, exit.AllowLookThrough, exit.IsOpen, exit.IsLocked,
+		exit.RequiresItemID, exit.ConsumesKey,
+		exit.ID,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update exit: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrExitNotFound, exit.ID)
+	}
+
+	return nil
+}
+
 // DeleteExit deletes an exit
 func DeleteExit(id string) error {
-	result, err := DB.Exec("DELETE FROM exits WHERE id = ?", id)
+	result, err := DB.Exec(rebind("DELETE FROM exits WHERE id = ?"), id)
 	if err != nil {
 		return fmt.Errorf("failed to delete exit: %w", err)
 	}
@@ -370,7 +756,7 @@ func DeleteExit(id string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("exit not found: %s", id)
+		return fmt.Errorf("%w: %s", ErrExitNotFound, id)
 	}
 
 	return nil
@@ -387,11 +773,11 @@ func CreateZone(zone *Zone) error {
 	zone.UpdatedAt = now
 
 	query := `
-		INSERT INTO zones (id, name, description, theme, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO zones (id, name, description, theme, respawn_room_id, ambiance, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := DB.Exec(query, zone.ID, zone.Name, zone.Description, zone.Theme, zone.CreatedAt, zone.UpdatedAt)
+	_, err := DB.Exec(rebind(query), zone.ID, zone.Name, zone.Description, zone.Theme, zone.RespawnRoomID, zone.Ambiance, zone.CreatedAt, zone.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create zone: %w", err)
 	}
@@ -402,11 +788,12 @@ func CreateZone(zone *Zone) error {
 // GetZone retrieves a zone by ID
 func GetZone(id string) (*Zone, error) {
 	zone := &Zone{}
+	var respawnRoomID sql.NullString
 
-	query := "SELECT id, name, description, theme, created_at, updated_at FROM zones WHERE id = ?"
+	query := "SELECT id, name, description, theme, respawn_room_id, ambiance, created_at, updated_at FROM zones WHERE id = ?"
 
-	err := DB.QueryRow(query, id).Scan(
-		&zone.ID, &zone.Name, &zone.Description, &zone.Theme, &zone.CreatedAt, &zone.UpdatedAt,
+	err := DB.QueryRow(rebind(query), id).Scan(
+		&zone.ID, &zone.Name, &zone.Description, &zone.Theme, &respawnRoomID, &zone.Ambiance, &zone.CreatedAt, &zone.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -416,14 +803,19 @@ func GetZone(id string) (*Zone, error) {
 		return nil, fmt.Errorf("failed to get zone: %w", err)
 	}
 
+	// Handle nullable respawn_room_id
+	if respawnRoomID.Valid {
+		zone.RespawnRoomID = &respawnRoomID.String
+	}
+
 	return zone, nil
 }
 
 // GetAllZones retrieves all zones
 func GetAllZones() ([]*Zone, error) {
-	query := "SELECT id, name, description, theme, created_at, updated_at FROM zones ORDER BY name"
+	query := "SELECT id, name, description, theme, respawn_room_id, ambiance, created_at, updated_at FROM zones ORDER BY name"
 
-	rows, err := DB.Query(query)
+	rows, err := DB.Query(rebind(query))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query zones: %w", err)
 	}
@@ -432,12 +824,113 @@ func GetAllZones() ([]*Zone, error) {
 	var zones []*Zone
 	for rows.Next() {
 		zone := &Zone{}
-		err := rows.Scan(&zone.ID, &zone.Name, &zone.Description, &zone.Theme, &zone.CreatedAt, &zone.UpdatedAt)
+		var respawnRoomID sql.NullString
+		err := rows.Scan(&zone.ID, &zone.Name, &zone.Description, &zone.Theme, &respawnRoomID, &zone.Ambiance, &zone.CreatedAt, &zone.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan zone: %w", err)
 		}
+		if respawnRoomID.Valid {
+			zone.RespawnRoomID = &respawnRoomID.String
+		}
 		zones = append(zones, zone)
 	}
 
 	return zones, nil
 }
+
+// SetZoneRespawnRoom sets the room players in a zone respawn at.
+// An empty roomID clears it so the zone falls back to the global respawn.
+//
+// A room in another zone is allowed, but outsideZone reports it so the
+// builder can be warned.
+func SetZoneRespawnRoom(zoneID, roomID string) (outsideZone bool, err error) {
+	var respawnRoomID *string
+	if roomID != "" {
+		room, err := GetRoom(roomID)
+		if err != nil {
+			return false, fmt.Errorf("invalid respawn room: %w", err)
+		}
+		outsideZone = room.ZoneID != zoneID
+		respawnRoomID = &roomID
+	}
+
+	result, err := DB.Exec(
+		rebind("UPDATE zones SET respawn_room_id = ?, updated_at = ? WHERE id = ?"),
+		respawnRoomID, time.Now(), zoneID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to set zone respawn room: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return false, fmt.Errorf("zone not found: %s", zoneID)
+	}
+
+	return outsideZone, nil
+}
+
+// ResolveRespawnRoom returns the room a player who died in roomID should
+// respawn in. The zone's respawn room is used when set and still present,
+// otherwise globalRespawnID is returned.
+func ResolveRespawnRoom(roomID, globalRespawnID string) string {
+	room, err := GetRoom(roomID)
+	if err != nil {
+		return globalRespawnID
+	}
+
+	zone, err := GetZone(room.ZoneID)
+	if err != nil || zone.RespawnRoomID == nil {
+		return globalRespawnID
+	}
+
+	// The respawn room may have been deleted since it was set
+	if _, err := GetRoom(*zone.RespawnRoomID); err != nil {
+		log.Printf("Warning: respawn room %s for zone %s no longer exists, using global respawn", *zone.RespawnRoomID, zone.ID)
+		return globalRespawnID
+	}
+
+	return *zone.RespawnRoomID
+}
+
+// SetZoneAmbiance sets the default ambiance for a zone's rooms.
+// An empty ambiance clears it.
+func SetZoneAmbiance(zoneID, ambiance string) error {
+	result, err := DB.Exec(
+		rebind("UPDATE zones SET ambiance = ?, updated_at = ? WHERE id = ?"),
+		ambiance, time.Now(), zoneID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set zone ambiance: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("zone not found: %s", zoneID)
+	}
+
+	return nil
+}
+
+// ResolveAmbiance returns the ambiance that applies to a room: its own if
+// set, otherwise its zone's. Empty means the room is silent.
+func ResolveAmbiance(room *Room) string {
+	if room.Ambiance != "" {
+		return room.Ambiance
+	}
+
+	zone, err := GetZone(room.ZoneID)
+	if err != nil {
+		return ""
+	}
+
+	return zone.Ambiance
+}
@@ -0,0 +1,510 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// createTestRoom creates a room in the Starting Area zone
+func createTestRoom(t *testing.T, title string) *Room {
+	t.Helper()
+
+	room := &Room{
+		ZoneID:      "10000000-0000-0000-0000-000000000001",
+		Title:       title,
+		Description: "A room made for testing.",
+		Terrain:     "indoor",
+	}
+	if err := CreateRoom(room); err != nil {
+		t.Fatalf("CreateRoom(%q): %v", title, err)
+	}
+
+	return room
+}
+
+// createTestExit creates an open, obvious exit between two rooms
+func createTestExit(t *testing.T, from, to *Room, keywords ...string) *Exit {
+	t.Helper()
+
+	exit := &Exit{
+		FromRoomID: from.ID,
+		ToRoomID:   to.ID,
+		Keywords:   keywords,
+		IsObvious:  true,
+		IsOpen:     true,
+	}
+	if err := CreateExit(exit); err != nil {
+		t.Fatalf("CreateExit(%v): %v", keywords, err)
+	}
+
+	return exit
+}
+
+func TestUpdateExit(t *testing.T) {
+	openTestDB(t)
+
+	hall := createTestRoom(t, "Hall")
+	vault := createTestRoom(t, "Vault")
+	exit := createTestExit(t, hall, vault, "north", "n")
+
+	key := &GameObject{Name: "an iron key", Description: "A heavy iron key.", ObjectType: "key"}
+	if err := CreateObject(key); err != nil {
+		t.Fatalf("CreateObject: %v", err)
+	}
+
+	exit.IsLocked = true
+	exit.IsOpen = false
+	exit.Keywords = []string{"door", "north"}
+	exit.Description = "A heavy iron door."
+	exit.RequiresItemID = &key.ID
+	if err := UpdateExit(exit); err != nil {
+		t.Fatalf("UpdateExit: %v", err)
+	}
+
+	got, err := GetExitByID(exit.ID)
+	if err != nil {
+		t.Fatalf("GetExitByID: %v", err)
+	}
+	if !got.IsLocked || got.IsOpen {
+		t.Errorf("locked, open = %v, %v; want true, false", got.IsLocked, got.IsOpen)
+	}
+	if !slices.Equal(got.Keywords, []string{"door", "north"}) {
+		t.Errorf("keywords = %v, want [door north]", got.Keywords)
+	}
+	if got.Description != "A heavy iron door." {
+		t.Errorf("description = %q, want %q", got.Description, "A heavy iron door.")
+	}
+	if got.RequiresItemID == nil || *got.RequiresItemID != key.ID {
+		t.Errorf("requires_item_id = %v, want %s", got.RequiresItemID, key.ID)
+	}
+
+	// Clearing the key must store NULL again
+	exit.RequiresItemID = nil
+	if err := UpdateExit(exit); err != nil {
+		t.Fatalf("UpdateExit clearing the key: %v", err)
+	}
+	got, err = GetExitByID(exit.ID)
+	if err != nil {
+		t.Fatalf("GetExitByID: %v", err)
+	}
+	if got.RequiresItemID != nil {
+		t.Errorf("requires_item_id = %q after clearing, want nil", *got.RequiresItemID)
+	}
+}
+
+func TestUpdateExitNotFound(t *testing.T) {
+	openTestDB(t)
+
+	err := UpdateExit(&Exit{ID: "no-such-exit", Keywords: []string{"north"}})
+	if !errors.Is(err, ErrExitNotFound) {
+		t.Errorf("UpdateExit of a missing exit = %v, want ErrExitNotFound", err)
+	}
+}
+
+func TestGetExitByID(t *testing.T) {
+	openTestDB(t)
+
+	hall := createTestRoom(t, "Hall")
+	yard := createTestRoom(t, "Yard")
+	exit := createTestExit(t, hall, yard, "east", "e")
+
+	got, err := GetExitByID(exit.ID)
+	if err != nil {
+		t.Fatalf("GetExitByID: %v", err)
+	}
+	if got.FromRoomID != hall.ID || got.ToRoomID != yard.ID {
+		t.Errorf("exit runs %s -> %s, want %s -> %s", got.FromRoomID, got.ToRoomID, hall.ID, yard.ID)
+	}
+	if !slices.Equal(got.Keywords, []string{"east", "e"}) {
+		t.Errorf("keywords = %v, want [east e]", got.Keywords)
+	}
+
+	if _, err := GetExitByID("no-such-exit"); !errors.Is(err, ErrExitNotFound) {
+		t.Errorf("GetExitByID of a missing exit = %v, want ErrExitNotFound", err)
+	}
+}
+
+func TestGetAllExits(t *testing.T) {
+	openTestDB(t)
+
+	before, err := GetAllExits()
+	if err != nil {
+		t.Fatalf("GetAllExits: %v", err)
+	}
+
+	hall := createTestRoom(t, "Hall")
+	yard := createTestRoom(t, "Yard")
+	created := []*Exit{
+		createTestExit(t, hall, yard, "east"),
+		createTestExit(t, yard, hall, "west"),
+	}
+
+	exits, err := GetAllExits()
+	if err != nil {
+		t.Fatalf("GetAllExits: %v", err)
+	}
+	if len(exits) != len(before)+len(created) {
+		t.Fatalf("got %d exits, want %d", len(exits), len(before)+len(created))
+	}
+
+	for _, want := range created {
+		if !slices.ContainsFunc(exits, func(e *Exit) bool { return e.ID == want.ID }) {
+			t.Errorf("exit %v (%s) missing from GetAllExits", want.Keywords, want.ID)
+		}
+	}
+}
+
+func TestDeleteRoomWithIncomingExits(t *testing.T) {
+	openTestDB(t)
+
+	hall := createTestRoom(t, "Hall")
+	vault := createTestRoom(t, "Vault")
+	in := createTestExit(t, hall, vault, "north")
+	createTestExit(t, vault, hall, "south")
+	createTestExit(t, vault, vault, "around")
+
+	incoming, err := DeleteRoom(vault.ID, false)
+	if !errors.Is(err, ErrRoomHasIncomingExits) {
+		t.Fatalf("DeleteRoom without force = %v, want ErrRoomHasIncomingExits", err)
+	}
+	if len(incoming) != 1 || incoming[0].ID != in.ID {
+		t.Errorf("DeleteRoom reported %d incoming exit(s), want only %s", len(incoming), in.ID)
+	}
+	if _, err := GetRoom(vault.ID); err != nil {
+		t.Errorf("room was deleted despite incoming exits: %v", err)
+	}
+
+	if _, err := DeleteRoom(vault.ID, true); err != nil {
+		t.Fatalf("DeleteRoom with force: %v", err)
+	}
+	if _, err := GetRoom(vault.ID); err == nil {
+		t.Error("room still exists after a forced delete")
+	}
+	if exits, err := GetExitsByRoom(hall.ID); err != nil || len(exits) != 0 {
+		t.Errorf("hall has %d exit(s) left (err %v), want the severed exit removed", len(exits), err)
+	}
+}
+
+func TestDeleteRoomFailureKeepsExits(t *testing.T) {
+	openTestDB(t)
+
+	hall := createTestRoom(t, "Hall")
+	vault := createTestRoom(t, "Vault")
+	createTestExit(t, hall, vault, "north")
+	createTestExit(t, vault, hall, "south")
+
+	// The guard's foreign key makes the room delete itself fail
+	createTestEntity(t, "a vault guard", vault)
+
+	if _, err := DeleteRoom(vault.ID, true); err == nil {
+		t.Fatal("DeleteRoom of an occupied room succeeded")
+	}
+
+	if exits, err := GetExitsByRoom(hall.ID); err != nil || len(exits) != 1 {
+		t.Errorf("hall has %d exit(s) (err %v), want its exit kept", len(exits), err)
+	}
+	if exits, err := GetExitsByRoom(vault.ID); err != nil || len(exits) != 1 {
+		t.Errorf("vault has %d exit(s) (err %v), want its exit kept", len(exits), err)
+	}
+}
+
+func TestGetExitsToRoom(t *testing.T) {
+	openTestDB(t)
+
+	square := createTestRoom(t, "Square")
+	var want []string
+	for _, title := range []string{"Bakery", "Smithy", "Chapel"} {
+		from := createTestRoom(t, title)
+		want = append(want, createTestExit(t, from, square, "square").ID)
+	}
+
+	// Exits leaving the room are not incoming
+	createTestExit(t, square, createTestRoom(t, "Alley"), "alley")
+
+	exits, err := GetExitsToRoom(square.ID)
+	if err != nil {
+		t.Fatalf("GetExitsToRoom: %v", err)
+	}
+
+	var got []string
+	for _, exit := range exits {
+		if exit.ToRoomID != square.ID {
+			t.Errorf("exit %s leads to %s, not the square", exit.ID, exit.ToRoomID)
+		}
+		got = append(got, exit.ID)
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("GetExitsToRoom = %v, want %v", got, want)
+	}
+}
+
+func TestGetExitsByRoomMalformedKeywords(t *testing.T) {
+	openTestDB(t)
+
+	hall := createTestRoom(t, "Hall")
+	good := createTestExit(t, hall, createTestRoom(t, "Kitchen"), "kitchen")
+	bad := createTestExit(t, hall, createTestRoom(t, "Cellar"), "cellar")
+
+	if _, err := DB.Exec("UPDATE exits SET keywords = 'not json' WHERE id = ?", bad.ID); err != nil {
+		t.Fatalf("corrupting keywords: %v", err)
+	}
+
+	exits, err := GetExitsByRoom(hall.ID)
+	if err != nil {
+		t.Fatalf("GetExitsByRoom: %v", err)
+	}
+	if len(exits) != 2 {
+		t.Fatalf("GetExitsByRoom returned %d exits, want 2", len(exits))
+	}
+
+	for _, exit := range exits {
+		switch exit.ID {
+		case good.ID:
+			if !slices.Equal(exit.Keywords, []string{"kitchen"}) {
+				t.Errorf("good exit keywords = %v, want [kitchen]", exit.Keywords)
+			}
+		case bad.ID:
+			if len(exit.Keywords) != 0 {
+				t.Errorf("malformed exit keywords = %v, want none", exit.Keywords)
+			}
+		default:
+			t.Errorf("unexpected exit %s", exit.ID)
+		}
+	}
+}
+
+func TestValidateRoomText(t *testing.T) {
+	prevTitle, prevDesc := roomTitleMaxLen.Load(), roomDescMaxLen.Load()
+	t.Cleanup(func() { SetRoomLimits(int(prevTitle), int(prevDesc)) })
+	SetRoomLimits(10, 40)
+
+	tests := []struct {
+		name        string
+		title       string
+		description string
+		wantErr     bool
+	}{
+		{"valid", "Hall", "A long hall.", false},
+		{"title at limit", "Great Hall", "A long hall.", false},
+		{"multibyte title at limit", "Café Hallé", "A long hall.", false},
+		{"description newlines and tabs", "Hall", "A long hall.\r\n\tPortraits line it.", false},
+		{"empty title", "", "A long hall.", true},
+		{"whitespace title", "  \t ", "A long hall.", true},
+		{"title over limit", "Great Halls", "A long hall.", true},
+		{"control character in title", "Hall\x1b[31m", "A long hall.", true},
+		{"newline in title", "Great\nHall", "A long hall.", true},
+		{"description over limit", "Hall", strings.Repeat("a", 41), true},
+		{"control character in description", "Hall", "A long \x1b[2Jhall.", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRoomText(&Room{Title: tt.title, Description: tt.description})
+			if tt.wantErr && !errors.Is(err, ErrInvalidRoom) {
+				t.Errorf("validateRoomText(%q, %q) = %v, want ErrInvalidRoom", tt.title, tt.description, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("validateRoomText(%q, %q) = %v, want nil", tt.title, tt.description, err)
+			}
+		})
+	}
+}
+
+// deleteRoomUnchecked deletes a room with foreign keys off, leaving any
+// exits into it dangling the way a hand edit or bad import would
+func deleteRoomUnchecked(t *testing.T, room *Room) {
+	t.Helper()
+
+	ctx := context.Background()
+	conn, err := DB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("getting a connection: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatalf("disabling foreign keys: %v", err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	if _, err := conn.ExecContext(ctx, "DELETE FROM rooms WHERE id = ?", room.ID); err != nil {
+		t.Fatalf("deleting room: %v", err)
+	}
+}
+
+func TestDanglingExits(t *testing.T) {
+	openTestDB(t)
+
+	hall := createTestRoom(t, "Hall")
+	gone := createTestRoom(t, "Collapsed Tunnel")
+	dangling := createTestExit(t, hall, gone, "tunnel")
+	createTestExit(t, hall, createTestRoom(t, "Kitchen"), "kitchen")
+
+	deleteRoomUnchecked(t, gone)
+
+	found, err := FindDanglingExits()
+	if err != nil {
+		t.Fatalf("FindDanglingExits: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != dangling.ID {
+		t.Fatalf("FindDanglingExits returned %d exit(s), want only %s", len(found), dangling.ID)
+	}
+
+	deleted, err := DeleteDanglingExits()
+	if err != nil {
+		t.Fatalf("DeleteDanglingExits: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != dangling.ID {
+		t.Errorf("DeleteDanglingExits removed %d exit(s), want only %s", len(deleted), dangling.ID)
+	}
+
+	exits, err := GetExitsByRoom(hall.ID)
+	if err != nil {
+		t.Fatalf("GetExitsByRoom: %v", err)
+	}
+	if len(exits) != 1 || exits[0].ID == dangling.ID {
+		t.Errorf("hall has %d exit(s) left, want only the kitchen exit", len(exits))
+	}
+
+	if deleted, err := DeleteDanglingExits(); err != nil || len(deleted) != 0 {
+		t.Errorf("second DeleteDanglingExits = %d exit(s), %v; want none", len(deleted), err)
+	}
+}
+
+func TestOppositeDirection(t *testing.T) {
+	tests := []struct {
+		direction string
+		want      string
+		wantOK    bool
+	}{
+		{"north", "south", true},
+		{"South", "north", true},
+		{"ne", "sw", true},
+		{"up", "down", true},
+		{"d", "u", true},
+		{"portal", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := OppositeDirection(tt.direction)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("OppositeDirection(%q) = %q, %v; want %q, %v", tt.direction, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestCreateTwoWayExit(t *testing.T) {
+	openTestDB(t)
+
+	hall := createTestRoom(t, "Hall")
+	vault := createTestRoom(t, "Vault")
+
+	exit := &Exit{FromRoomID: hall.ID, ToRoomID: vault.ID, Keywords: []string{"north"}, IsObvious: true, IsOpen: true}
+	reverse, err := CreateTwoWayExit(exit)
+	if err != nil {
+		t.Fatalf("CreateTwoWayExit: %v", err)
+	}
+	if reverse == nil {
+		t.Fatal("CreateTwoWayExit returned no reverse exit")
+	}
+	if reverse.FromRoomID != vault.ID || reverse.ToRoomID != hall.ID || !slices.Equal(reverse.Keywords, []string{"south"}) {
+		t.Errorf("reverse exit goes %s -> %s as %v, want vault -> hall as [south]",
+			reverse.FromRoomID, reverse.ToRoomID, reverse.Keywords)
+	}
+	if _, err := GetExitByID(reverse.ID); err != nil {
+		t.Errorf("reverse exit was not saved: %v", err)
+	}
+}
+
+func TestCreateTwoWayExitReverseTaken(t *testing.T) {
+	openTestDB(t)
+
+	hall := createTestRoom(t, "Hall")
+	vault := createTestRoom(t, "Vault")
+	cellar := createTestRoom(t, "Cellar")
+	createTestExit(t, vault, cellar, "South", "s")
+
+	exit := &Exit{FromRoomID: hall.ID, ToRoomID: vault.ID, Keywords: []string{"north"}, IsObvious: true, IsOpen: true}
+	reverse, err := CreateTwoWayExit(exit)
+	if err != nil {
+		t.Fatalf("CreateTwoWayExit: %v", err)
+	}
+	if reverse != nil {
+		t.Errorf("CreateTwoWayExit made reverse exit %s although the vault already has a south exit", reverse.ID)
+	}
+	if _, err := GetExitByID(exit.ID); err != nil {
+		t.Errorf("forward exit was not saved: %v", err)
+	}
+	if exits, err := GetExitsByRoom(vault.ID); err != nil || len(exits) != 1 {
+		t.Errorf("vault has %d exit(s) (err %v), want only its existing one", len(exits), err)
+	}
+}
+
+func TestCreateTwoWayExitRollsBack(t *testing.T) {
+	openTestDB(t)
+
+	hall := createTestRoom(t, "Hall")
+	vault := createTestRoom(t, "Vault")
+
+	// Make the reverse insert, and only that one, fail
+	_, err := DB.Exec(`
+CREATE TRIGGER fail_reverse_exit BEFORE INSERT ON exits
+WHEN NEW.from_room_id = '` + vault.ID + `'
+BEGIN
+    SELECT RAISE(ABORT, 'reverse exit refused');
+END`)
+	if err != nil {
+		t.Fatalf("creating trigger: %v", err)
+	}
+
+	exit := &Exit{FromRoomID: hall.ID, ToRoomID: vault.ID, Keywords: []string{"north"}, IsObvious: true, IsOpen: true}
+	if _, err := CreateTwoWayExit(exit); err == nil {
+		t.Fatal("CreateTwoWayExit succeeded although the reverse exit failed")
+	}
+
+	if exits, err := GetExitsByRoom(hall.ID); err != nil || len(exits) != 0 {
+		t.Errorf("hall has %d exit(s) (err %v), want the forward exit rolled back", len(exits), err)
+	}
+}
+
+func TestSetZoneRespawnRoom(t *testing.T) {
+	openTestDB(t)
+
+	const zoneID = "10000000-0000-0000-0000-000000000001"
+	shrine := createTestRoom(t, "Shrine")
+
+	outside, err := SetZoneRespawnRoom(zoneID, shrine.ID)
+	if err != nil || outside {
+		t.Errorf("SetZoneRespawnRoom in the zone = %v, %v; want false, nil", outside, err)
+	}
+	if got := ResolveRespawnRoom(shrine.ID, StartingRoomID); got != shrine.ID {
+		t.Errorf("ResolveRespawnRoom = %s, want the shrine %s", got, shrine.ID)
+	}
+
+	// Limbo is in the Staff Area zone
+	outside, err = SetZoneRespawnRoom(zoneID, LimboRoomID)
+	if err != nil || !outside {
+		t.Errorf("SetZoneRespawnRoom outside the zone = %v, %v; want true, nil", outside, err)
+	}
+
+	outside, err = SetZoneRespawnRoom(zoneID, "")
+	if err != nil || outside {
+		t.Errorf("clearing SetZoneRespawnRoom = %v, %v; want false, nil", outside, err)
+	}
+	if got := ResolveRespawnRoom(shrine.ID, StartingRoomID); got != StartingRoomID {
+		t.Errorf("ResolveRespawnRoom after clearing = %s, want the global %s", got, StartingRoomID)
+	}
+
+	if _, err := SetZoneRespawnRoom(zoneID, "no-such-room"); err == nil {
+		t.Error("SetZoneRespawnRoom with a missing room succeeded")
+	}
+	if _, err := SetZoneRespawnRoom("no-such-zone", shrine.ID); err == nil {
+		t.Error("SetZoneRespawnRoom of a missing zone succeeded")
+	}
+}
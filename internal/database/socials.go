@@ -0,0 +1,129 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Social represents a canned emote such as smile or wave.
+// Templates use {actor} for the player performing the social and
+// {target} for the player it is aimed at.
+type Social struct {
+	Verb string `json:"verb"`
+
+	// Untargeted variant: "smile"
+	NoTargetSelf string `json:"no_target_self"`
+	NoTargetRoom string `json:"no_target_room"`
+
+	// Targeted variant: "smile bob"
+	TargetSelf   string `json:"target_self"`
+	TargetVictim string `json:"target_victim"`
+	TargetRoom   string `json:"target_room"`
+}
+
+// defaultSocials are seeded when the database is first created
+var defaultSocials = []*Social{
+	{"smile", "You smile.", "{actor} smiles.", "You smile at {target}.", "{actor} smiles at you.", "{actor} smiles at {target}."},
+	{"wave", "You wave.", "{actor} waves.", "You wave at {target}.", "{actor} waves at you.", "{actor} waves at {target}."},
+	{"bow", "You bow gracefully.", "{actor} bows gracefully.", "You bow before {target}.", "{actor} bows before you.", "{actor} bows before {target}."},
+	{"nod", "You nod.", "{actor} nods.", "You nod at {target}.", "{actor} nods at you.", "{actor} nods at {target}."},
+	{"laugh", "You laugh.", "{actor} laughs.", "You laugh at {target}.", "{actor} laughs at you.", "{actor} laughs at {target}."},
+	{"shrug", "You shrug.", "{actor} shrugs.", "You shrug at {target}.", "{actor} shrugs at you.", "{actor} shrugs at {target}."},
+}
+
+// CreateSocial adds a new social
+func CreateSocial(social *Social) error {
+	query := `
+		INSERT INTO socials (
+			verb, no_target_self, no_target_room,
+			target_self, target_victim, target_room
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := DB.Exec(rebind(query),
+		social.Verb, social.NoTargetSelf, social.NoTargetRoom,
+		social.TargetSelf, social.TargetVictim, social.TargetRoom,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create social: %w", err)
+	}
+
+	return nil
+}
+
+// GetSocial retrieves a social by verb
+func GetSocial(verb string) (*Social, error) {
+	social := &Social{}
+
+	query := `
+		SELECT verb, no_target_self, no_target_room, target_self, target_victim, target_room
+		FROM socials
+		WHERE verb = ?
+	`
+
+	err := DB.QueryRow(rebind(query), verb).Scan(
+		&social.Verb, &social.NoTargetSelf, &social.NoTargetRoom,
+		&social.TargetSelf, &social.TargetVictim, &social.TargetRoom,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("social not found: %s", verb)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get social: %w", err)
+	}
+
+	return social, nil
+}
+
+// GetAllSocials retrieves all socials, used to register them as commands
+func GetAllSocials() ([]*Social, error) {
+	query := `
+		SELECT verb, no_target_self, no_target_room, target_self, target_victim, target_room
+		FROM socials
+		ORDER BY verb
+	`
+
+	rows, err := DB.Query(rebind(query))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query socials: %w", err)
+	}
+	defer rows.Close()
+
+	var socials []*Social
+	for rows.Next() {
+		social := &Social{}
+		err := rows.Scan(
+			&social.Verb, &social.NoTargetSelf, &social.NoTargetRoom,
+			&social.TargetSelf, &social.TargetVictim, &social.TargetRoom,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan social: %w", err)
+		}
+		socials = append(socials, social)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read socials: %w", err)
+	}
+
+	return socials, nil
+}
+
+// DeleteSocial removes a social
+func DeleteSocial(verb string) error {
+	result, err := DB.Exec(rebind("DELETE FROM socials WHERE verb = ?"), verb)
+	if err != nil {
+		return fmt.Errorf("failed to delete social: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("social not found: %s", verb)
+	}
+
+	return nil
+}
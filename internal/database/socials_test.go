@@ -0,0 +1,55 @@
+package database
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSocialCRUD(t *testing.T) {
+	openTestDB(t)
+
+	poke := &Social{
+		Verb:         "poke",
+		NoTargetSelf: "You poke the air.",
+		NoTargetRoom: "{actor} pokes the air.",
+		TargetSelf:   "You poke {target}.",
+		TargetVictim: "{actor} pokes you.",
+		TargetRoom:   "{actor} pokes {target}.",
+	}
+	if err := CreateSocial(poke); err != nil {
+		t.Fatalf("CreateSocial: %v", err)
+	}
+	if err := CreateSocial(poke); err == nil {
+		t.Error("CreateSocial with an existing verb succeeded")
+	}
+
+	got, err := GetSocial("poke")
+	if err != nil {
+		t.Fatalf("GetSocial: %v", err)
+	}
+	if *got != *poke {
+		t.Errorf("GetSocial = %+v, want %+v", got, poke)
+	}
+
+	all, err := GetAllSocials()
+	if err != nil {
+		t.Fatalf("GetAllSocials: %v", err)
+	}
+	if len(all) != len(defaultSocials)+1 {
+		t.Errorf("GetAllSocials returned %d socials, want the %d defaults and poke", len(all), len(defaultSocials))
+	}
+	if !slices.IsSortedFunc(all, func(a, b *Social) int { return strings.Compare(a.Verb, b.Verb) }) {
+		t.Error("GetAllSocials is not sorted by verb")
+	}
+
+	if err := DeleteSocial("poke"); err != nil {
+		t.Fatalf("DeleteSocial: %v", err)
+	}
+	if _, err := GetSocial("poke"); err == nil {
+		t.Error("GetSocial found the social after it was deleted")
+	}
+	if err := DeleteSocial("poke"); err == nil {
+		t.Error("DeleteSocial of a missing social succeeded")
+	}
+}
@@ -0,0 +1,97 @@
+package game
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// TickHandler is called once per game tick with the tick number, starting at 1
+type TickHandler func(tick uint64)
+
+// Ticker drives periodic game updates by calling registered handlers on a
+// fixed interval. Handlers run one after another on the ticker's goroutine,
+// so a slow handler delays the next tick rather than overlapping it.
+type Ticker struct {
+	interval time.Duration
+	handlers []namedHandler
+	stop     chan struct{}
+	done     chan struct{}
+	mu       sync.Mutex
+}
+
+type namedHandler struct {
+	name    string
+	handler TickHandler
+}
+
+// NewTicker creates a ticker firing every interval. Call Start to run it.
+func NewTicker(interval time.Duration) *Ticker {
+	return &Ticker{
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Interval returns the time between ticks
+func (t *Ticker) Interval() time.Duration {
+	return t.interval
+}
+
+// Register adds a handler, called on every tick in registration order
+func (t *Ticker) Register(name string, handler TickHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.handlers = append(t.handlers, namedHandler{name: name, handler: handler})
+}
+
+// Start runs the tick loop in a new goroutine
+func (t *Ticker) Start() {
+	go t.run()
+}
+
+// Stop ends the tick loop and waits for a tick in progress to finish
+func (t *Ticker) Stop() {
+	close(t.stop)
+	<-t.done
+}
+
+// run fires the handlers every interval until Stop is called
+func (t *Ticker) run() {
+	defer close(t.done)
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	var tick uint64
+	for {
+		select {
+		case <-ticker.C:
+			tick++
+			t.fire(tick)
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// fire calls each handler, recovering from panics so one broken handler
+// can't stop the game clock
+func (t *Ticker) fire(tick uint64) {
+	t.mu.Lock()
+	handlers := append([]namedHandler(nil), t.handlers...)
+	t.mu.Unlock()
+
+	for _, h := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Tick handler %s panicked on tick %d: %v", h.name, tick, r)
+				}
+			}()
+			h.handler(tick)
+		}()
+	}
+}